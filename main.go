@@ -3,9 +3,15 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"syscall"
+
+	"split/splitter"
 )
 
 func main() {
@@ -16,9 +22,18 @@ func main() {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
-	lineCount, fileCount, byteSize, suffixLen, args := res.LineCount, res.FileCount, res.ByteSize, res.SuffixLen, res.Args
+	lineCount, fileCount, byteSize, suffixLen, pattern, args := res.LineCount, res.FileCount, res.ByteSize, res.SuffixLen, res.Pattern, res.Args
 
-	err = IllegalArgsChecker(Args{lineCount, fileCount, byteSize, args})
+	err = IllegalArgsChecker(Args{
+		LineCount:  lineCount,
+		FileCount:  fileCount,
+		ByteSize:   byteSize,
+		LineBytes:  res.LineBytes,
+		Pattern:    pattern,
+		ChunkIndex: res.ChunkIndex,
+		Report:     res.Report != "",
+		Args:       args,
+	})
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
@@ -26,7 +41,7 @@ func main() {
 
 	nonFlagArgs := fs.Args()
 	reader := bufio.NewReader(os.Stdin)
-	splitFileName, err := GetFileName(nonFlagArgs, reader)
+	splitFileName, err := GetFileName(nonFlagArgs, reader, res.Prompt)
 	if err != nil {
 		fmt.Printf("Error getting the file name: %v\n", err)
 		os.Exit(1)
@@ -37,40 +52,94 @@ func main() {
 		prefixFileName = nonFlagArgs[1]
 	}
 
-	file, err := os.Open(splitFileName)
-	if err != nil {
-		fmt.Printf("Error opening the file: %v\n", err)
+	opts := splitter.Options{
+		Lines:      lineCount,
+		Chunks:     fileCount,
+		ChunkMode:  res.ChunkMode,
+		ChunkIndex: res.ChunkIndex,
+		ChunkOut:   os.Stdout,
+		Bytes:      byteSize,
+		LineBytes:  res.LineBytes,
+		Pattern:    pattern,
+		// Describing every split file is only worth it when they are reported.
+		CollectResults: res.Report != "",
+	}
+	if opts.Lines == 0 && opts.Chunks == 0 && opts.Bytes == 0 && opts.LineBytes == 0 && opts.Pattern == "" {
+		fmt.Println("Please specify a splitting option (-l, -n, -b, -C, -p).")
 		os.Exit(1)
 	}
 
-	defer func() {
-		err := file.Close()
+	opts.Output = splitter.Output{
+		Dir:         res.OutputDir,
+		DirMode:     res.MkdirMode,
+		Force:       res.Force,
+		Fsync:       res.Fsync,
+		KeepPartial: res.KeepPartial,
+		ElideEmpty:  res.ElideEmpty,
+		Filter:      res.Filter,
+		Prefix:      prefixFileName,
+		SuffixLen:   suffixLen,
+		SuffixKind:  res.SuffixKind,
+		SuffixStart: res.SuffixStart,
+		// Like GNU split, suffixes only grow when neither their length nor their start was given.
+		WidenSuffix:      !res.SuffixLenSet && res.SuffixStart == 0,
+		AdditionalSuffix: res.AdditionalSuffix,
+	}
+	if res.NameTemplate != "" {
+		opts.Template, err = splitter.ParseNameTemplate(res.NameTemplate)
 		if err != nil {
-			fmt.Printf("Error closing the file: %v\n", err)
+			fmt.Printf("%v\n", err)
 			os.Exit(1)
 		}
+	}
+
+	if res.Verbose {
+		opts.Verbose = os.Stdout
+	}
+
+	// An interrupt or the --timeout cancels the split, which then removes the files it has written.
+	// After the first signal the default handling is restored, so a second one stops the program at once.
+	parent := context.Background()
+	if res.Timeout > 0 {
+		var cancel context.CancelFunc
+		parent, cancel = context.WithTimeout(parent, res.Timeout)
+		defer cancel()
+	}
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
 	}()
 
-	if lineCount > 0 {
-		err := SplitByLinesMultithread(file, lineCount, prefixFileName, suffixLen)
+	var input io.Reader = os.Stdin
+	if splitFileName != StdinFileName {
+		file, err := os.Open(splitFileName)
 		if err != nil {
-			fmt.Printf("%v\n", err)
+			fmt.Printf("Error opening the file: %v\n", err)
 			os.Exit(1)
 		}
-	} else if fileCount > 0 {
-		err := SplitByFileCountsMultithread(file, fileCount, prefixFileName, suffixLen)
-		if err != nil {
-			fmt.Printf("%v\n", err)
-			os.Exit(1)
-		}
-	} else if byteSize > 0 {
-		err := SplitByBytesMultithread(file, byteSize, prefixFileName, suffixLen)
-		if err != nil {
-			fmt.Printf("%v\n", err)
+
+		defer func() {
+			err := file.Close()
+			if err != nil {
+				fmt.Printf("Error closing the file: %v\n", err)
+				os.Exit(1)
+			}
+		}()
+		input = file
+	}
+
+	result, err := splitter.Split(ctx, input, opts)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+
+	if res.Report != "" {
+		if err := WriteReport(res.Report, result); err != nil {
+			fmt.Println(err.Error())
 			os.Exit(1)
 		}
-	} else {
-		fmt.Println("Please specify a splitting option (-l, -n, -b).")
-		os.Exit(1)
 	}
 }
@@ -0,0 +1,98 @@
+package splitter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// The errors Split and the functions of this package fail with can be told apart with errors.Is.
+// A split file that already exists and may not be overwritten matches fs.ErrExist,
+// and a cancelled split matches the error of its context.
+var (
+	// ErrNoMode is returned by Split when Options gives no way of splitting.
+	ErrNoMode = errors.New("no way of splitting is given")
+	// ErrInvalidOptions is returned by Split for Options that don't fit together, such as more than one way of
+	// splitting or a ChunkIndex without ChunkOut.
+	ErrInvalidOptions = errors.New("invalid options")
+	// ErrInvalidCount is a line count, byte count, number of files or chunk index that is out of range.
+	ErrInvalidCount = errors.New("invalid count")
+	// ErrInvalidPattern is a pattern that is not a valid regular expression.
+	ErrInvalidPattern = errors.New("invalid pattern")
+	// ErrInvalidSuffix is a suffix length or start value the suffixes can't have.
+	ErrInvalidSuffix = errors.New("invalid suffix")
+	// ErrInvalidTemplate is a name template that can't be parsed or can't be used with the split mode.
+	ErrInvalidTemplate = errors.New("invalid name template")
+	// ErrUnknownStrategy is a name NewStrategy has no Strategy registered under.
+	ErrUnknownStrategy = errors.New("unknown strategy")
+	// ErrTooManyParts is returned before anything is written when the suffixes can't name all of the split files.
+	ErrTooManyParts = errors.New("too many files")
+	// ErrSuffixExhausted is returned when the suffixes run out while the input is still being split.
+	ErrSuffixExhausted = errors.New("suffixes exhausted")
+	// ErrNegativeAdvance is returned when a Strategy takes a negative number of bytes.
+	ErrNegativeAdvance = errors.New("strategy returned negative advance count")
+	// ErrAdvanceTooFar is returned when a Strategy takes more bytes than it was given.
+	ErrAdvanceTooFar = errors.New("strategy returned advance count beyond input")
+	// ErrEmptyPart is returned when a Strategy ends a split file before it has any content.
+	ErrEmptyPart = errors.New("empty split file")
+	// ErrFilterFailed is returned when the filter command of a split file fails.
+	ErrFilterFailed = errors.New("filter failed")
+)
+
+// PartError is the failure of one split file, such as a full disk or a filter command that failed.
+// Its message is that of Err, which errors.Is and errors.As see through.
+type PartError struct {
+	// Index is the index of the split file, counting from 0.
+	Index int
+	// Name is the name of the split file.
+	Name string
+	Err  error
+}
+
+// Error is a method that returns the message of the underlying error.
+func (e *PartError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap is a method that returns the underlying error.
+func (e *PartError) Unwrap() error {
+	return e.Err
+}
+
+// kindError is an error with a message of its own that also matches one of the errors above with errors.Is.
+type kindError struct {
+	kind error
+	err  error
+}
+
+// errorOf is a function that formats an error like fmt.Errorf, %w included, and makes it match kind.
+func errorOf(kind error, format string, a ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, a...)}
+}
+
+// Error is a method that returns the formatted message.
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap is a method that returns both the kind and the formatted error.
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// joinPartErrors is a function that joins the failures of several split files with errors.Join,
+// in the order of the files, so that the result doesn't depend on which goroutine failed first.
+func joinPartErrors(errs []error) error {
+	index := func(err error) int {
+		var partErr *PartError
+		if errors.As(err, &partErr) {
+			return partErr.Index
+		}
+		return -1
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return index(errs[i]) < index(errs[j]) })
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
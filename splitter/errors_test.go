@@ -0,0 +1,127 @@
+package splitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+func TestSplitErrorKinds(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "xab"), []byte("previous run\n"), 0o644)
+	tmpl, _ := ParseNameTemplate("{prefix}{total}{suffix}")
+
+	tests := []struct {
+		opts     Options
+		expected error
+	}{
+		{Options{}, ErrNoMode},
+		{Options{Lines: -1}, ErrInvalidCount},
+		{Options{Chunks: 2, ChunkIndex: 3, ChunkOut: io.Discard}, ErrInvalidCount},
+		{Options{Chunks: 2, ChunkIndex: 1}, ErrInvalidOptions},
+		{Options{Lines: 1, Bytes: 2}, ErrInvalidOptions},
+		{Options{Pattern: "1", Strategy: ByLines(1)}, ErrInvalidOptions},
+		{Options{Chunks: 2, ChunkMode: ChunkRoundRobin, ChunkIndex: 1}, ErrInvalidOptions},
+		{Options{Pattern: "("}, ErrInvalidPattern},
+		{Options{Lines: 1, Output: Output{SuffixLen: -1}}, ErrInvalidSuffix},
+		{Options{Lines: 1, Output: Output{Sink: &MemorySink{}, SuffixLen: 1, Template: tmpl}}, ErrInvalidTemplate},
+		{Options{Chunks: 27, Output: Output{Sink: &MemorySink{}, SuffixLen: 1}}, ErrTooManyParts},
+		{Options{Lines: 1, Output: Output{Sink: &MemorySink{}, SuffixLen: 1}}, ErrSuffixExhausted},
+		{Options{Chunks: 3, Output: Output{Dir: dir, SuffixLen: 2}}, fs.ErrExist},
+	}
+
+	for i, test := range tests {
+		input := strings.Repeat("1\n", 30)
+		_, err := Split(context.Background(), strings.NewReader(input), test.opts)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, err)
+		}
+	}
+}
+
+func TestNewStrategyErrorKinds(t *testing.T) {
+	if _, err := NewStrategy("lines", "x"); !errors.Is(err, ErrInvalidCount) {
+		t.Errorf("expected %v, got %v", ErrInvalidCount, err)
+	}
+	if _, err := NewStrategy("avro", ""); !errors.Is(err, ErrUnknownStrategy) {
+		t.Errorf("expected %v, got %v", ErrUnknownStrategy, err)
+	}
+}
+
+// failingSink is a Sink whose files fail with err when they are closed. When wg is set, every Close waits for
+// the others first, so that all of the files fail at once.
+type failingSink struct {
+	err error
+	wg  *sync.WaitGroup
+}
+
+// failingFile is a split file of a failingSink.
+type failingFile struct {
+	sink *failingSink
+}
+
+func (s *failingSink) Create(ctx context.Context, part PartInfo) (io.WriteCloser, error) {
+	return failingFile{sink: s}, nil
+}
+
+func (f failingFile) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (f failingFile) Close() error {
+	if f.sink.wg != nil {
+		f.sink.wg.Done()
+		f.sink.wg.Wait()
+	}
+	return f.sink.err
+}
+
+func TestSplitPartError(t *testing.T) {
+	_, err := Split(context.Background(), strings.NewReader("1\n"), Options{Lines: 1, Output: Output{Sink: &failingSink{err: syscall.ENOSPC}, SuffixLen: 2}})
+
+	if !errors.Is(err, syscall.ENOSPC) {
+		t.Errorf("expected %v, got %v", syscall.ENOSPC, err)
+	}
+	var partErr *PartError
+	if !errors.As(err, &partErr) {
+		t.Fatalf("expected a *PartError, got %T", err)
+	}
+	if partErr.Index != 0 || partErr.Name != "xaa" {
+		t.Errorf("expected %v and %v, got %v and %v", 0, "xaa", partErr.Index, partErr.Name)
+	}
+}
+
+func TestSplitJoinsPartErrors(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	wg.Add(3)
+	tests := []Options{
+		{Chunks: 3, Output: Output{Sink: &failingSink{err: syscall.ENOSPC, wg: wg}, SuffixLen: 2}},
+		{Chunks: 3, ChunkMode: ChunkRoundRobin, Output: Output{Sink: &failingSink{err: syscall.ENOSPC}, SuffixLen: 2}},
+	}
+
+	for _, opts := range tests {
+		_, err := Split(context.Background(), strings.NewReader("1\n2\n3\n"), opts)
+
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			t.Fatalf("expected joined errors, got %v", err)
+		}
+		errs := joined.Unwrap()
+		if len(errs) != 3 {
+			t.Fatalf("expected %v errors, got %v", 3, errs)
+		}
+		for i, err := range errs {
+			var partErr *PartError
+			if !errors.As(err, &partErr) || partErr.Index != i {
+				t.Errorf("expected the failure of file %v, got %v", i, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package splitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// FilterSink is a Sink that pipes every split file to a run of Command with "sh -c" instead of writing it,
+// with $FILE set to the name of the split file.
+// Like GNU split, the output of the command goes to standard output and standard error.
+type FilterSink struct {
+	Command string
+}
+
+// filterPart is a split file that is piped to a run of the filter command instead of being written.
+type filterPart struct {
+	ctx   context.Context
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	name  string
+	// done is set once the command has stopped reading, after which the rest of the content is dropped.
+	done bool
+}
+
+// Create is a method that starts the command for the split file. The command is killed when ctx is cancelled.
+func (f *FilterSink) Create(ctx context.Context, part PartInfo) (io.WriteCloser, error) {
+	name := part.Name
+	cmd := exec.CommandContext(ctx, "sh", "-c", f.Command)
+	cmd.Env = append(os.Environ(), "FILE="+name)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error: filter for %s: %w", name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error: filter for %s: %w", name, err)
+	}
+	return &filterPart{ctx: ctx, cmd: cmd, stdin: stdin, name: name}, nil
+}
+
+// Write is a method that passes p to the command. A command that exits without reading all of its input,
+// such as head, is not an error in itself; its exit status decides.
+func (f *filterPart) Write(p []byte) (int, error) {
+	if f.done {
+		return len(p), nil
+	}
+	n, err := f.stdin.Write(p)
+	if errors.Is(err, syscall.EPIPE) {
+		f.done = true
+		return len(p), nil
+	}
+	return n, err
+}
+
+// Close is a method that closes the input of the command and waits for it to exit.
+func (f *filterPart) Close() error {
+	closeErr := f.stdin.Close()
+	if err := f.cmd.Wait(); err != nil {
+		// A command killed because the split was cancelled didn't fail by itself.
+		if ctxErr := f.ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("error: filter for %s: %w", f.name, ctxErr)
+		}
+		return errorOf(ErrFilterFailed, "error: filter failed for %s: %w", f.name, err)
+	}
+	if closeErr != nil && !errors.Is(closeErr, syscall.EPIPE) && !errors.Is(closeErr, os.ErrClosed) {
+		return fmt.Errorf("error: filter for %s: %w", f.name, closeErr)
+	}
+	return nil
+}
+
+// Abort is a method that stops the command.
+func (f *filterPart) Abort() {
+	if f.cmd.ProcessState != nil {
+		return
+	}
+	_ = f.stdin.Close()
+	_ = f.cmd.Process.Kill()
+	_ = f.cmd.Wait()
+}
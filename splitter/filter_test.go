@@ -0,0 +1,85 @@
+package splitter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitWithFilter(t *testing.T) {
+	const filter = `tr 0-9 a-j > "$FILE.out"`
+	tests := []struct {
+		split    func(dir string) error
+		expected map[string]string
+	}{
+		{func(dir string) error {
+			return splitByLinesMultithread(strings.NewReader("1\n2\n3\n"), 2, Output{Dir: dir, SuffixLen: 2, Filter: filter})
+		}, map[string]string{"xaa.out": "b\nc\n", "xab.out": "d\n"}},
+		{func(dir string) error {
+			return splitByFileCountsMultithread(strings.NewReader("1\n2\n3\n"), 2, Output{Dir: dir, SuffixLen: 2, Filter: filter})
+		}, map[string]string{"xaa.out": "b\nc", "xab.out": "\nd\n"}},
+		{func(dir string) error {
+			return splitByRoundRobin(strings.NewReader("1\n3\n2\n"), 2, Output{Dir: dir, SuffixLen: 2, Filter: filter})
+		}, map[string]string{"xaa.out": "b\nc\n", "xab.out": "d\n"}},
+		{func(dir string) error {
+			return splitByRoundRobin(strings.NewReader("1\n2\n"), 4, Output{Dir: dir, SuffixLen: 2, Filter: filter, ElideEmpty: true})
+		}, map[string]string{"xaa.out": "b\n", "xab.out": "c\n"}},
+		{func(dir string) error {
+			return splitByRoundRobin(strings.NewReader("1\n2\n"), 4, Output{Dir: dir, SuffixLen: 2, Filter: filter})
+		}, map[string]string{"xaa.out": "b\n", "xab.out": "c\n", "xac.out": "", "xad.out": ""}},
+	}
+
+	for i, test := range tests {
+		dir := t.TempDir()
+		if err := test.split(dir); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+
+		res := map[string]string{}
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			content, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+			res[entry.Name()] = string(content)
+		}
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, res)
+		}
+	}
+}
+
+func TestSplitWithFilterFailure(t *testing.T) {
+	dir := t.TempDir()
+	err := splitByLinesMultithread(strings.NewReader("1\n2\n"), 1, Output{Dir: dir, SuffixLen: 2, Filter: `[ "$(basename "$FILE")" != xab ]`})
+
+	expected := "error: filter failed for " + filepath.Join(dir, "xab") + ": exit status 1"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestSplitWithFilterNotReadingInput(t *testing.T) {
+	dir := t.TempDir()
+	input := strings.Repeat("0123456789\n", 100000)
+
+	err := splitByBytesMultithread(strings.NewReader(input), int64(len(input)), Output{Dir: dir, SuffixLen: 2, Filter: "exit 0"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSplitByRoundRobinElidedFilterIsNotRun(t *testing.T) {
+	var verbose bytes.Buffer
+	out := Output{SuffixLen: 2, Filter: "cat > /dev/null", ElideEmpty: true, Verbose: &verbose}
+
+	if err := splitByRoundRobin(strings.NewReader("1\n2\n"), 4, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "executing with FILE=xaa\nexecuting with FILE=xab\n"
+	if verbose.String() != expected {
+		t.Errorf("expected %q, got %q", expected, verbose.String())
+	}
+}
@@ -0,0 +1,362 @@
+package splitter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Output is how the split files are named: Prefix, or "x" when it is empty, followed by a suffix
+// of SuffixLen characters (DefaultSuffixLen when it is 0) of the given kind and AdditionalSuffix. Numeric and hexadecimal suffixes
+// start counting at SuffixStart. With WidenSuffix, the suffix grows instead of running out (see Suffixes).
+// AdditionalSuffix must not contain a slash.
+// When Template is set, it decides the whole name instead.
+// When Dir is set, the names are put under it.
+//
+// Sink is where the files go. When it is nil, the files are piped to Filter when that is set (see FilterSink),
+// and written to the local file system otherwise (see DirSink, which gets Force and Fsync).
+// When the files go to a DirSink, a missing Dir is created with DirMode (0777 when zero).
+// With ElideEmpty, the empty files that splitting into a number of files (-n) can leave are not created.
+// Verbose, when set, gets a line for every file as it is created.
+// When the split fails or its context is cancelled, the files it created are removed again if the sink is a Remover,
+// unless KeepPartial is set.
+type Output struct {
+	Sink             Sink
+	Force            bool
+	Fsync            bool
+	KeepPartial      bool
+	ElideEmpty       bool
+	Filter           string
+	Verbose          io.Writer
+	Dir              string
+	DirMode          os.FileMode
+	Prefix           string
+	SuffixLen        int
+	SuffixKind       SuffixKind
+	SuffixStart      int
+	WidenSuffix      bool
+	AdditionalSuffix string
+	Template         *NameTemplate
+
+	ctx     context.Context
+	created *createdParts
+	results *results
+}
+
+// PartInfo describes one split file. Fields the split mode can't know are left zero.
+type PartInfo struct {
+	// Name is the name of the file, including Output.Dir.
+	Name string
+	// Index is the number of the file, counting from 0.
+	Index int
+	// Suffix is the generated suffix of the file.
+	Suffix string
+	// Total is the number of files the input is split into.
+	Total int
+	// Offset is the position in the input of the first byte of the file.
+	Offset int64
+	// StartLine and EndLine are the numbers of the first and the last line in the file, counting from 1.
+	StartLine int64
+	EndLine   int64
+}
+
+// context is a method that returns the context of the split, or a context that is never cancelled when there is none.
+func (o Output) context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// suffixes is a method that returns the suffixes of the split files.
+func (o Output) suffixes() (*Suffixes, error) {
+	length := o.SuffixLen
+	if length == 0 {
+		length = DefaultSuffixLen
+	}
+	return NewSuffixes(o.SuffixKind, length, o.SuffixStart, o.WidenSuffix)
+}
+
+// firstSuffixes is a method that returns the suffixes of the first fileCount split files.
+func (o Output) firstSuffixes(fileCount int) ([]string, error) {
+	suffixes, err := o.suffixes()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := suffixes.Suffix(fileCount - 1); !ok {
+		return nil, errorOf(ErrTooManyParts, "error: too many files")
+	}
+
+	strs := make([]string, fileCount)
+	for i := range strs {
+		strs[i], _ = suffixes.Suffix(i)
+	}
+	return strs, nil
+}
+
+// prepare is a method that validates the additional suffix, and the name template against the fields the split
+// mode provides, then creates the output directory and resolves it to an absolute path.
+// Split modes call it once before creating any file, so their goroutines never race on the directory.
+func (o *Output) prepare(provided ...string) error {
+	if hasPathSeparator(o.AdditionalSuffix) {
+		return errorOf(ErrInvalidTemplate, "error: %s: additional suffix must not contain a slash", o.AdditionalSuffix)
+	}
+	if o.Template != nil {
+		if err := o.Template.Check(provided...); err != nil {
+			return err
+		}
+	}
+	if o.Sink == nil && o.Filter != "" {
+		o.Sink = &FilterSink{Command: o.Filter}
+	}
+	if o.Sink == nil {
+		o.Sink = &DirSink{Force: o.Force, Fsync: o.Fsync}
+	}
+	// The finished files are only kept track of when rollback could remove them.
+	o.created = nil
+	if _, ok := o.Sink.(Remover); ok && !o.KeepPartial {
+		o.created = &createdParts{}
+	}
+	if _, ok := o.Sink.(*DirSink); !ok || o.Dir == "" {
+		return nil
+	}
+
+	mode := o.DirMode
+	if mode == 0 {
+		mode = 0o777
+	}
+	if err := os.MkdirAll(o.Dir, mode); err != nil {
+		return fmt.Errorf("error: creating output directory: %w", err)
+	}
+	dir, err := filepath.Abs(o.Dir)
+	if err != nil {
+		return fmt.Errorf("error: resolving output directory: %w", err)
+	}
+	o.Dir = dir
+	return nil
+}
+
+// name is a method that returns the file name of the part.
+func (o Output) name(part PartInfo) string {
+	prefix := o.Prefix
+	if prefix == "" {
+		prefix = "x"
+	}
+	name := prefix + part.Suffix + o.AdditionalSuffix
+	if o.Template != nil {
+		name = o.Template.Expand(prefix, o.AdditionalSuffix, part)
+	}
+	if o.Dir != "" {
+		name = filepath.Join(o.Dir, name)
+	}
+	return name
+}
+
+// checkNotExist is a method that returns an error if any of the named files already exists in a DirSink
+// that can't overwrite them. Split modes that know every name up front call it before writing anything.
+func (o Output) checkNotExist(names []string) error {
+	if sink, ok := o.Sink.(*DirSink); !ok || sink.Force {
+		return nil
+	}
+	for i, name := range names {
+		if _, err := os.Lstat(name); err == nil {
+			return &PartError{Index: i, Name: name, Err: fmt.Errorf("error creating file: %s: %w, use --force to overwrite it", name, fs.ErrExist)}
+		}
+	}
+	return nil
+}
+
+// rollback is a method that removes every file created so far from a Remover sink when err is not nil,
+// unless KeepPartial is set,
+// so that a failed or interrupted run leaves no half-written set of files behind.
+// Split modes call it once all of their writes have finished.
+func (o Output) rollback(err error) error {
+	remover, ok := o.Sink.(Remover)
+	if err != nil && !o.KeepPartial && ok && o.created != nil {
+		o.created.removeAll(remover)
+	}
+	return err
+}
+
+// writeToFile is a method that writes the given content to the split file of the part through the sink,
+// giving up when ctx is cancelled. A failure is returned as a *PartError.
+func (o Output) writeToFile(ctx context.Context, content io.Reader, part PartInfo) error {
+	part.Name = o.name(part)
+	file, err := o.Sink.Create(ctx, part)
+	if err != nil {
+		return &PartError{Index: part.Index, Name: part.Name, Err: err}
+	}
+
+	written := &countingWriter{w: file}
+	buffer := copyBuffers.Get().(*[]byte)
+	_, err = io.CopyBuffer(written, contextReader{ctx: ctx, r: content}, *buffer)
+	copyBuffers.Put(buffer)
+	if err != nil {
+		abortPart(file)
+		return &PartError{Index: part.Index, Name: part.Name, Err: fmt.Errorf("error writing to the file: %w", err)}
+	}
+	if err := file.Close(); err != nil {
+		return &PartError{Index: part.Index, Name: part.Name, Err: err}
+	}
+	o.created.add(part)
+	o.record(part, written, true)
+	return nil
+}
+
+// copyBuffers are the buffers writeToFile copies through. The counting writer and the context reader hide
+// io.WriterTo and io.ReaderFrom, so without them every split file would allocate a buffer of its own.
+var copyBuffers = sync.Pool{New: func() any {
+	buffer := make([]byte, 32*1024)
+	return &buffer
+}}
+
+// contextReader is a reader that fails with the error of ctx once ctx is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read is a method that reads from the underlying reader unless the context is cancelled.
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// readDeadliner is a reader whose blocked reads can be interrupted by a deadline, such as a pipe opened as an
+// *os.File or a net.Conn.
+type readDeadliner interface {
+	io.Reader
+	SetReadDeadline(t time.Time) error
+}
+
+// newCancelableReader is a function that makes the reads of r that can block for long return once ctx is cancelled.
+// The returned release function has to be called once r is no longer read; it leaves r without a read deadline.
+//
+// Regular files and in-memory readers never block for long, so they are returned as they are, which also lets the
+// split modes that need to seek use them directly. A reader with a read deadline gets one in the past when ctx is
+// cancelled, which interrupts a blocked read without leaving anything behind. Only an *os.File that can't have a
+// deadline, such as a standard input in blocking mode, is read from goroutines (see cancelableReader). Any other
+// reader is returned as it is, since a goroutine could go on reading it after Split has returned; such a reader is
+// only checked between reads, and its caller has to close it to interrupt a read that is waiting for input.
+func newCancelableReader(ctx context.Context, r io.Reader) (io.Reader, func()) {
+	switch r := r.(type) {
+	case *bytes.Reader, *bytes.Buffer, *strings.Reader:
+		return r, func() {}
+	case *os.File:
+		if fileInfo, err := r.Stat(); err == nil && fileInfo.Mode().IsRegular() {
+			return r, func() {}
+		}
+	}
+	if d, ok := r.(readDeadliner); ok && d.SetReadDeadline(time.Time{}) == nil {
+		interrupted := make(chan struct{})
+		stop := context.AfterFunc(ctx, func() {
+			_ = d.SetReadDeadline(time.Now())
+			close(interrupted)
+		})
+		release := func() {
+			if !stop() {
+				<-interrupted
+			}
+			_ = d.SetReadDeadline(time.Time{})
+		}
+		return deadlineReader{ctx: ctx, r: d}, release
+	}
+	if _, ok := r.(*os.File); ok {
+		return &cancelableReader{ctx: ctx, r: r}, func() {}
+	}
+	return r, func() {}
+}
+
+// deadlineReader is a reader whose read deadline is set when ctx is cancelled, and which then fails with the
+// error of ctx instead of os.ErrDeadlineExceeded.
+type deadlineReader struct {
+	ctx context.Context
+	r   readDeadliner
+}
+
+// Read is a method that reads from the underlying reader until it returns or its deadline passes.
+func (d deadlineReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err != nil && errors.Is(err, os.ErrDeadlineExceeded) && d.ctx.Err() != nil {
+		err = d.ctx.Err()
+	}
+	return n, err
+}
+
+// readResult is the outcome of a read done by a cancelableReader.
+type readResult struct {
+	n   int
+	err error
+}
+
+// cancelableReader is a reader whose reads return as soon as ctx is cancelled, even when the underlying reader
+// is blocked, such as on a pipe nothing is written to. Every read runs in a goroutine of its own with a buffer of
+// its own, so a read given up on never writes to the caller's buffer; it ends whenever the underlying reader returns,
+// and what it reads by then is lost.
+type cancelableReader struct {
+	ctx context.Context
+	r   io.Reader
+	buf []byte
+}
+
+// Read is a method that reads from the underlying reader until it returns or ctx is cancelled.
+func (c *cancelableReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if len(c.buf) < len(p) {
+		c.buf = make([]byte, len(p))
+	}
+	buf := c.buf[:len(p)]
+	done := make(chan readResult, 1)
+	go func() {
+		n, err := c.r.Read(buf)
+		done <- readResult{n: n, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return copy(p, buf[:res.n]), res.err
+	case <-c.ctx.Done():
+		// The read still owns buf, so the next one can't use it.
+		c.buf = nil
+		return 0, c.ctx.Err()
+	}
+}
+
+// createdParts records the names of the split files a run has finished, so that they can be removed again.
+// A nil createdParts records nothing, for runs that would never remove them.
+type createdParts struct {
+	mu    sync.Mutex
+	names []string
+}
+
+// add is a method that records a finished split file.
+func (c *createdParts) add(part PartInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.names = append(c.names, part.Name)
+}
+
+// removeAll is a method that removes every recorded split file from the sink.
+func (c *createdParts) removeAll(remover Remover) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range c.names {
+		_ = remover.Remove(name)
+	}
+	c.names = nil
+}
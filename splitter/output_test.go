@@ -0,0 +1,158 @@
+package splitter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestWriteToFileLeavesNoPartialFile(t *testing.T) {
+	dir := t.TempDir()
+	content := io.MultiReader(strings.NewReader("complete line\n"), iotest.ErrReader(errors.New("read failed")))
+
+	out := Output{Dir: dir}
+	_ = out.prepare()
+	err := out.writeToFile(context.Background(), content, PartInfo{Suffix: "aa"})
+	if err == nil {
+		t.Fatalf("expected an error, got nil")
+	}
+
+	res, _ := fileNamesWithPattern(filepath.Join(dir, "*"))
+	hidden, _ := fileNamesWithPattern(filepath.Join(dir, ".*"))
+	if len(res) != 0 || len(hidden) != 0 {
+		t.Errorf("expected no files, got %v and %v", res, hidden)
+	}
+}
+
+func TestWriteToFileRenamesIntoPlace(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "xaa")
+
+	for _, out := range []Output{{Dir: dir}, {Dir: dir, Fsync: true}, {Dir: dir, Force: true}} {
+		_ = os.Remove(name)
+		_ = out.prepare()
+		if err := out.writeToFile(context.Background(), strings.NewReader("1\n2\n"), PartInfo{Suffix: "aa"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		entries, _ := os.ReadDir(dir)
+		names := []string{}
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		if !reflect.DeepEqual(names, []string{"xaa"}) {
+			t.Errorf("expected %v, got %v", []string{"xaa"}, names)
+		}
+		content, _ := os.ReadFile(name)
+		if string(content) != "1\n2\n" {
+			t.Errorf("expected %q, got %q", "1\n2\n", string(content))
+		}
+		info, _ := os.Stat(name)
+		if info.Mode().Perm() != 0o666&^umask() {
+			t.Errorf("expected %v, got %v", 0o666&^umask(), info.Mode().Perm())
+		}
+	}
+}
+
+func TestWriteToFileNoClobber(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "xaa")
+	_ = os.WriteFile(name, []byte("previous run\n"), 0o644)
+
+	out := Output{Dir: dir}
+	_ = out.prepare()
+	err := out.writeToFile(context.Background(), strings.NewReader("new\n"), PartInfo{Suffix: "aa"})
+	if !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected an error because %v exists, got %v", name, err)
+	}
+	content, _ := os.ReadFile(name)
+	if string(content) != "previous run\n" {
+		t.Errorf("expected %v to be untouched, got %q", name, string(content))
+	}
+	hidden, _ := fileNamesWithPattern(filepath.Join(dir, ".*"))
+	if len(hidden) != 0 {
+		t.Errorf("expected no temporary files, got %v", hidden)
+	}
+
+	out = Output{Dir: dir, Force: true}
+	_ = out.prepare()
+	err = out.writeToFile(context.Background(), strings.NewReader("new\n"), PartInfo{Suffix: "aa"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(name)
+	if string(content) != "new\n" {
+		t.Errorf("expected %q, got %q", "new\n", string(content))
+	}
+}
+
+func TestPrepareAdditionalSuffixWithSlash(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Split(context.Background(), strings.NewReader("1\n"), Options{Lines: 1, Output: Output{Dir: dir, SuffixLen: 2, AdditionalSuffix: "/../x"}})
+	expected := "error: /../x: additional suffix must not contain a slash"
+	if !errors.Is(err, ErrInvalidTemplate) || err.Error() != expected {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("expected no files, got %v", entries)
+	}
+}
+
+func TestWriteToFileReusesCopyBuffer(t *testing.T) {
+	out := Output{Sink: &MemorySink{}, SuffixLen: 2}
+	_ = out.prepare()
+	content := bytes.NewReader(nil)
+	write := func() {
+		content.Reset([]byte("1"))
+		_ = out.writeToFile(context.Background(), content, PartInfo{Suffix: "aa"})
+	}
+	write()
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	before := stats.TotalAlloc
+	for i := 0; i < 100; i++ {
+		write()
+	}
+	runtime.ReadMemStats(&stats)
+	if perWrite := (stats.TotalAlloc - before) / 100; perWrite >= 32*1024 {
+		t.Errorf("expected the copy buffer to be reused, got %v bytes allocated per write", perWrite)
+	}
+}
+
+func TestNewCancelableReaderOnlyWrapsReadersThatBlock(t *testing.T) {
+	pipeReader, pipeWriter := io.Pipe()
+	defer func() { _ = pipeWriter.Close() }()
+	tests := []io.Reader{strings.NewReader("1\n"), bytes.NewReader([]byte("1\n")), bytes.NewBufferString("1\n"), pipeReader}
+
+	for _, r := range tests {
+		wrapped, release := newCancelableReader(context.Background(), r)
+		release()
+		if wrapped != r {
+			t.Errorf("%T: expected the reader itself, got %T", r, wrapped)
+		}
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	defer func() { _ = w.Close() }()
+	wrapped, release := newCancelableReader(context.Background(), r)
+	release()
+	if _, ok := wrapped.(deadlineReader); !ok {
+		t.Errorf("expected a %T, got %T", deadlineReader{}, wrapped)
+	}
+}
@@ -0,0 +1,109 @@
+package splitter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Result describes the split files written by Split, in order.
+type Result struct {
+	Parts []PartResult `json:"parts"`
+}
+
+// PartResult describes one split file.
+// The offsets are the range of the input the file holds; they are left out when splitting round robin,
+// since such a file holds lines from all over the input.
+type PartResult struct {
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	Bytes       int64  `json:"bytes"`
+	Lines       int64  `json:"lines"`
+	StartOffset *int64 `json:"start_offset,omitempty"`
+	EndOffset   *int64 `json:"end_offset,omitempty"`
+}
+
+// results collects a PartResult for every split file the goroutines of a split write.
+type results struct {
+	mu    sync.Mutex
+	parts []PartResult
+}
+
+// add is a method that records a split file.
+func (r *results) add(part PartResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parts = append(r.parts, part)
+}
+
+// result is a method that returns the recorded split files as a Result, in order.
+// Without results, which is when they weren't asked for, the Result is empty.
+func (r *results) result() Result {
+	if r == nil {
+		return Result{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	parts := append([]PartResult{}, r.parts...)
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Index < parts[j].Index })
+	return Result{Parts: parts}
+}
+
+// countingWriter is a writer that counts the bytes and lines written through it.
+type countingWriter struct {
+	w        io.Writer
+	bytes    int64
+	newlines int64
+	last     byte
+}
+
+// Write is a method that writes p to the underlying writer and counts it.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.bytes += int64(n)
+	for _, b := range p[:n] {
+		if b == '\n' {
+			c.newlines++
+		}
+	}
+	if n > 0 {
+		c.last = p[n-1]
+	}
+	return n, err
+}
+
+// lines is a method that returns the number of lines written, counting a last line without a newline.
+func (c *countingWriter) lines() int64 {
+	if c.bytes > 0 && c.last != '\n' {
+		return c.newlines + 1
+	}
+	return c.newlines
+}
+
+// announce is a method that prints, when Verbose is set, that the named split file is about to be created.
+// Split modes call it in the order of the files, before the file is handed to a goroutine.
+func (o Output) announce(name string) {
+	if o.Verbose == nil {
+		return
+	}
+	if _, ok := o.Sink.(*FilterSink); ok {
+		_, _ = fmt.Fprintf(o.Verbose, "executing with FILE=%s\n", name)
+		return
+	}
+	_, _ = fmt.Fprintf(o.Verbose, "creating file '%s'\n", name)
+}
+
+// record is a method that adds the split file to the results of the split, when they are collected.
+// withOffsets tells whether the part holds a single range of the input starting at part.Offset.
+func (o Output) record(part PartInfo, written *countingWriter, withOffsets bool) {
+	if o.results == nil {
+		return
+	}
+	res := PartResult{Index: part.Index, Name: part.Name, Bytes: written.bytes, Lines: written.lines()}
+	if withOffsets {
+		start, end := part.Offset, part.Offset+written.bytes
+		res.StartOffset, res.EndOffset = &start, &end
+	}
+	o.results.add(res)
+}
@@ -0,0 +1,61 @@
+package splitter
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitByLinesMultithreadReport(t *testing.T) {
+	dir := t.TempDir()
+	var verbose bytes.Buffer
+	rep := &results{}
+
+	err := splitByLinesMultithread(strings.NewReader("1\n2\n3\n4\n5"), 2, Output{Dir: dir, SuffixLen: 2, Verbose: &verbose, results: rep})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "creating file '" + filepath.Join(dir, "xaa") + "'\n" +
+		"creating file '" + filepath.Join(dir, "xab") + "'\n" +
+		"creating file '" + filepath.Join(dir, "xac") + "'\n"
+	if verbose.String() != expected {
+		t.Errorf("expected %q, got %q", expected, verbose.String())
+	}
+
+	res, _ := json.Marshal(rep.result())
+	expected = `{"parts":[` +
+		`{"index":0,"name":"` + filepath.Join(dir, "xaa") + `","bytes":4,"lines":2,"start_offset":0,"end_offset":4},` +
+		`{"index":1,"name":"` + filepath.Join(dir, "xab") + `","bytes":4,"lines":2,"start_offset":4,"end_offset":8},` +
+		`{"index":2,"name":"` + filepath.Join(dir, "xac") + `","bytes":1,"lines":1,"start_offset":8,"end_offset":9}]}`
+	if string(res) != expected {
+		t.Errorf("expected %v, got %v", expected, string(res))
+	}
+}
+
+func TestSplitByRoundRobinReport(t *testing.T) {
+	dir := t.TempDir()
+	rep := &results{}
+
+	err := splitByRoundRobin(strings.NewReader("1\n2\n3\n"), 2, Output{Dir: dir, SuffixLen: 2, results: rep})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := json.Marshal(rep.result())
+	expected := `{"parts":[` +
+		`{"index":0,"name":"` + filepath.Join(dir, "xaa") + `","bytes":4,"lines":2},` +
+		`{"index":1,"name":"` + filepath.Join(dir, "xab") + `","bytes":2,"lines":1}]}`
+	if string(res) != expected {
+		t.Errorf("expected %v, got %v", expected, string(res))
+	}
+}
+
+func TestResultWithoutParts(t *testing.T) {
+	res, _ := json.Marshal((&results{}).result())
+	if string(res) != `{"parts":[]}` {
+		t.Errorf("expected %v, got %v", `{"parts":[]}`, string(res))
+	}
+}
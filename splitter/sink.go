@@ -0,0 +1,343 @@
+package splitter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Sink is where the split files go. Split modes call Create for every split file, from several goroutines at once,
+// write the content of the file to the returned writer and Close it to finish the file.
+//
+// A file that won't be finished because the split failed is thrown away with Abort when the writer is an Aborter,
+// and is closed otherwise. When the split fails, the files already finished are removed again if the Sink is a
+// Remover (see Output.KeepPartial).
+type Sink interface {
+	Create(ctx context.Context, part PartInfo) (io.WriteCloser, error)
+}
+
+// Aborter is a writer returned by Sink.Create that can throw its split file away instead of finishing it.
+type Aborter interface {
+	Abort()
+}
+
+// Remover is a Sink that can remove a split file it has finished, given its name.
+type Remover interface {
+	Remove(name string) error
+}
+
+// abortPart is a function that throws away a split file that won't be finished.
+func abortPart(w io.WriteCloser) {
+	if aborter, ok := w.(Aborter); ok {
+		aborter.Abort()
+		return
+	}
+	_ = w.Close()
+}
+
+// DirSink is a Sink that writes every split file to the local file system under its name, which includes Output.Dir.
+// A file is written under a hidden temporary name next to it first and only gets its name once it is complete,
+// so a crash or a failed write never leaves a partial file under the final name.
+// Existing files are never overwritten unless Force is set. With Fsync, every file is flushed to disk
+// before it gets its name.
+type DirSink struct {
+	Force bool
+	Fsync bool
+}
+
+// Create is a method that creates the hidden temporary file for the split file.
+// Unless Force is set, a split file that already exists fails here, before any of its content is written;
+// the name is checked again when the file is complete, in case it was taken in the meantime.
+func (d *DirSink) Create(ctx context.Context, part PartInfo) (io.WriteCloser, error) {
+	if !d.Force {
+		if _, err := os.Lstat(part.Name); err == nil {
+			return nil, fmt.Errorf("error creating file: %s: %w, use --force to overwrite it", part.Name, fs.ErrExist)
+		}
+	}
+	dir, base := filepath.Split(part.Name)
+	for i := 0; ; i++ {
+		tmpName := filepath.Join(dir, fmt.Sprintf(".%s.%d.tmp", base, rand.Uint32()))
+		file, err := os.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o666)
+		if errors.Is(err, fs.ErrExist) && i < 100 {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error creating file: %w", err)
+		}
+		return &partFile{file: file, name: part.Name, tmpName: tmpName, sink: d}, nil
+	}
+}
+
+// Remove is a method that removes the split file.
+func (d *DirSink) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// partFile is a split file being written under a temporary name.
+type partFile struct {
+	file    *os.File
+	name    string
+	tmpName string
+	sink    *DirSink
+	closed  bool
+}
+
+// Write is a method that writes to the temporary file.
+func (p *partFile) Write(b []byte) (int, error) {
+	return p.file.Write(b)
+}
+
+// Close is a method that closes the file, flushes it to disk when Fsync is set, and moves it to its final name.
+// An existing file with that name is replaced only when Force is set.
+func (p *partFile) Close() error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	if p.sink.Fsync {
+		if err := p.file.Sync(); err != nil {
+			_ = p.file.Close()
+			_ = os.Remove(p.tmpName)
+			return fmt.Errorf("error syncing the file: %w", err)
+		}
+	}
+	if err := p.file.Close(); err != nil {
+		_ = os.Remove(p.tmpName)
+		return fmt.Errorf("error closing the file: %w", err)
+	}
+	defer func() { _ = os.Remove(p.tmpName) }()
+
+	if p.sink.Force {
+		if err := os.Rename(p.tmpName, p.name); err != nil {
+			return fmt.Errorf("error creating file: %w", err)
+		}
+	} else if err := moveNoClobber(p.tmpName, p.name); err != nil {
+		return err
+	}
+
+	if p.sink.Fsync {
+		syncDir(filepath.Dir(p.name))
+	}
+	return nil
+}
+
+// Abort is a method that closes and removes the file without giving it its final name.
+func (p *partFile) Abort() {
+	if p.closed {
+		return
+	}
+	p.closed = true
+	_ = p.file.Close()
+	_ = os.Remove(p.tmpName)
+}
+
+// linkFile is os.Link, which tests replace to act like a file system without hard links.
+var linkFile = os.Link
+
+// moveNoClobber is a function that gives the complete file tmpName the name name, unless a file with that name exists.
+// Unlike a rename, a link fails when the name is taken, so an existing file is never replaced. File systems without
+// hard links, such as vfat, exFAT and many network and FUSE mounts, instead get the name reserved by creating it
+// exclusively, after which the file is renamed over the empty placeholder.
+func moveNoClobber(tmpName string, name string) error {
+	err := linkFile(tmpName, name)
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.EOPNOTSUPP) ||
+		errors.Is(err, syscall.EXDEV) || errors.Is(err, syscall.ENOSYS) {
+		var placeholder *os.File
+		placeholder, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o666)
+		if err == nil {
+			_ = placeholder.Close()
+			if err = os.Rename(tmpName, name); err != nil {
+				_ = os.Remove(name)
+			}
+		}
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("error creating file: %s: %w, use --force to overwrite it", name, fs.ErrExist)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+	return nil
+}
+
+// syncDir is a function that flushes a directory to disk, so that a rename in it survives a crash.
+// Not every platform can sync a directory, so failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// MemorySink is a Sink that keeps every split file in memory, mostly for tests.
+// The zero value is ready to use.
+type MemorySink struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// memoryFile is a split file being written to a MemorySink.
+type memoryFile struct {
+	bytes.Buffer
+	name string
+	sink *MemorySink
+}
+
+// Create is a method that starts an empty split file.
+func (m *MemorySink) Create(ctx context.Context, part PartInfo) (io.WriteCloser, error) {
+	return &memoryFile{name: part.Name, sink: m}, nil
+}
+
+// Remove is a method that removes the split file.
+func (m *MemorySink) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, name)
+	return nil
+}
+
+// Files is a method that returns the content of every split file by name.
+func (m *MemorySink) Files() map[string][]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	files := make(map[string][]byte, len(m.files))
+	for name, content := range m.files {
+		files[name] = content
+	}
+	return files
+}
+
+// Close is a method that stores the split file in the sink.
+func (f *memoryFile) Close() error {
+	f.sink.mu.Lock()
+	defer f.sink.mu.Unlock()
+	if f.sink.files == nil {
+		f.sink.files = map[string][]byte{}
+	}
+	f.sink.files[f.name] = f.Bytes()
+	return nil
+}
+
+// Abort is a method that drops the split file.
+func (f *memoryFile) Abort() {}
+
+// TarSink is a Sink that writes every split file as an entry of a tar archive.
+// An entry needs its size up front, so each file is spooled to a temporary file until it is complete.
+// Entries are added in the order the files are finished, which is not always the order of the files.
+// Close has to be called after the split to finish the archive.
+type TarSink struct {
+	mu sync.Mutex
+	tw *tar.Writer
+}
+
+// NewTarSink is a function that returns a TarSink writing the archive to w.
+func NewTarSink(w io.Writer) *TarSink {
+	return &TarSink{tw: tar.NewWriter(w)}
+}
+
+// Create is a method that starts spooling the split file.
+func (t *TarSink) Create(ctx context.Context, part PartInfo) (io.WriteCloser, error) {
+	return newSpoolFile(part.Name, func(name string, content *os.File, size int64) error {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		header := &tar.Header{Name: name, Mode: 0o644, Size: size, ModTime: time.Now(), Typeflag: tar.TypeReg}
+		if err := t.tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := io.Copy(t.tw, content)
+		return err
+	})
+}
+
+// Close is a method that finishes the archive.
+func (t *TarSink) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tw.Close()
+}
+
+// ZipSink is a Sink that writes every split file as an entry of a zip archive.
+// Only one entry can be written at a time, so each file is spooled to a temporary file until it is complete.
+// Entries are added in the order the files are finished, which is not always the order of the files.
+// Close has to be called after the split to finish the archive.
+type ZipSink struct {
+	mu sync.Mutex
+	zw *zip.Writer
+}
+
+// NewZipSink is a function that returns a ZipSink writing the archive to w.
+func NewZipSink(w io.Writer) *ZipSink {
+	return &ZipSink{zw: zip.NewWriter(w)}
+}
+
+// Create is a method that starts spooling the split file.
+func (z *ZipSink) Create(ctx context.Context, part PartInfo) (io.WriteCloser, error) {
+	return newSpoolFile(part.Name, func(name string, content *os.File, size int64) error {
+		z.mu.Lock()
+		defer z.mu.Unlock()
+		entry, err := z.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(entry, content)
+		return err
+	})
+}
+
+// Close is a method that finishes the archive.
+func (z *ZipSink) Close() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.zw.Close()
+}
+
+// spoolFile is a split file kept in a temporary file until it is complete and handed to add.
+type spoolFile struct {
+	*os.File
+	name string
+	add  func(name string, content *os.File, size int64) error
+}
+
+// newSpoolFile is a function that creates the temporary file for a spoolFile.
+func newSpoolFile(name string, add func(string, *os.File, int64) error) (*spoolFile, error) {
+	file, err := os.CreateTemp("", "split-part-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating file: %w", err)
+	}
+	return &spoolFile{File: file, name: filepath.ToSlash(name), add: add}, nil
+}
+
+// Close is a method that hands the complete split file to add and removes the temporary file.
+func (s *spoolFile) Close() error {
+	defer s.Abort()
+	size, err := s.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = s.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		err = s.add(s.name, s.File, size)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s to the archive: %w", s.name, err)
+	}
+	return nil
+}
+
+// Abort is a method that removes the temporary file.
+func (s *spoolFile) Abort() {
+	_ = s.File.Close()
+	_ = os.Remove(s.File.Name())
+}
@@ -0,0 +1,171 @@
+package splitter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMemorySink(t *testing.T) {
+	sink := &MemorySink{}
+
+	_, err := Split(context.Background(), strings.NewReader("1\n2\n3\n"), Options{Lines: 2, Output: Output{Sink: sink, SuffixLen: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string][]byte{"xaa": []byte("1\n2\n"), "xab": []byte("3\n")}
+	if !reflect.DeepEqual(sink.Files(), expected) {
+		t.Errorf("expected %q, got %q", expected, sink.Files())
+	}
+}
+
+func TestMemorySinkRemovesPartialOutput(t *testing.T) {
+	sink := &MemorySink{}
+	input := io.MultiReader(strings.NewReader("1\n2\n3\n"), iotest.ErrReader(errors.New("read failed")))
+
+	_, err := Split(context.Background(), input, Options{Lines: 1, Output: Output{Sink: sink, SuffixLen: 2}})
+	if err == nil {
+		t.Fatalf("expected an error, got nil")
+	}
+
+	if len(sink.Files()) != 0 {
+		t.Errorf("expected no files to be left, got %q", sink.Files())
+	}
+}
+
+func TestTarSink(t *testing.T) {
+	var archive bytes.Buffer
+	sink := NewTarSink(&archive)
+
+	_, err := Split(context.Background(), strings.NewReader("1\n2\n3\n"), Options{Chunks: 3, ChunkMode: ChunkRoundRobin, Output: Output{Sink: sink, SuffixLen: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := map[string]string{}
+	tr := tar.NewReader(&archive)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		res[header.Name] = string(content)
+	}
+
+	expected := map[string]string{"xaa": "1\n", "xab": "2\n", "xac": "3\n"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestZipSink(t *testing.T) {
+	var archive bytes.Buffer
+	sink := NewZipSink(&archive)
+
+	_, err := Split(context.Background(), strings.NewReader("one\ntwo\nthree\n"), Options{Chunks: 2, ChunkMode: ChunkLines, Output: Output{Sink: sink, SuffixLen: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res := map[string]string{}
+	for _, file := range zr.File {
+		r, _ := file.Open()
+		content, _ := io.ReadAll(r)
+		_ = r.Close()
+		res[file.Name] = string(content)
+	}
+
+	expected := map[string]string{"xaa": "one\ntwo\n", "xab": "three\n"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestDirSinkWithoutHardLinks(t *testing.T) {
+	defer func(link func(string, string) error) { linkFile = link }(linkFile)
+	linkFile = func(oldname string, newname string) error {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: syscall.EPERM}
+	}
+
+	dir := t.TempDir()
+	_, err := Split(context.Background(), strings.NewReader("1\n2\n"), Options{Lines: 1, Output: Output{Dir: dir, SuffixLen: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "xab"))
+	if string(content) != "2\n" {
+		t.Errorf("expected %q, got %q", "2\n", string(content))
+	}
+
+	_, err = Split(context.Background(), strings.NewReader("3\n"), Options{Lines: 1, Output: Output{Dir: dir, SuffixLen: 2}})
+	if !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected %v, got %v", fs.ErrExist, err)
+	}
+	content, _ = os.ReadFile(filepath.Join(dir, "xaa"))
+	if string(content) != "1\n" {
+		t.Errorf("expected %q, got %q", "1\n", string(content))
+	}
+	hidden, _ := filepath.Glob(filepath.Join(dir, ".*"))
+	if len(hidden) != 0 {
+		t.Errorf("expected no temporary files, got %v", hidden)
+	}
+
+	// A name taken after Create is still never overwritten.
+	tmpName := filepath.Join(dir, ".xaa.tmp")
+	_ = os.WriteFile(tmpName, []byte("3\n"), 0o644)
+	err = moveNoClobber(tmpName, filepath.Join(dir, "xaa"))
+	if !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected %v, got %v", fs.ErrExist, err)
+	}
+	content, _ = os.ReadFile(filepath.Join(dir, "xaa"))
+	if string(content) != "1\n" {
+		t.Errorf("expected %q, got %q", "1\n", string(content))
+	}
+}
+
+func TestDirSinkCreateExisting(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "xaa")
+	_ = os.WriteFile(name, []byte("previous run\n"), 0o644)
+
+	_, err := (&DirSink{}).Create(context.Background(), PartInfo{Name: name})
+	if !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected %v, got %v", fs.ErrExist, err)
+	}
+	hidden, _ := filepath.Glob(filepath.Join(dir, ".*"))
+	if len(hidden) != 0 {
+		t.Errorf("expected no temporary files, got %v", hidden)
+	}
+
+	file, err := (&DirSink{Force: true}).Create(context.Background(), PartInfo{Name: name})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	abortPart(file)
+}
@@ -0,0 +1,570 @@
+package splitter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// splitByLinesMultithread is a function that splits a file by the number of lines using goroutines (see ByLines).
+func splitByLinesMultithread(r io.Reader, lineCount int, out Output) error {
+	return splitStream(r, ByLines(lineCount), out)
+}
+
+// splitByLineBytesMultithread is a function that splits a file into files of at most lineBytes bytes using goroutines.
+// Each file gets as many whole lines as fit; only a line longer than lineBytes is broken across files (see ByLineBytes).
+func splitByLineBytesMultithread(r io.Reader, lineBytes int64, out Output) error {
+	return splitStream(r, ByLineBytes(lineBytes), out)
+}
+
+// splitByPattern is a function that splits a file so that every line matching the pattern starts a new file.
+// The pattern is matched against each line without its line ending (see ByPattern).
+func splitByPattern(r io.Reader, pattern string, out Output) error {
+	strategy, err := ByPattern(pattern)
+	if err != nil {
+		return err
+	}
+	return splitStream(r, strategy, out)
+}
+
+// chunkWriter is a bounded set of goroutines that write chunks to files while the next chunk is read.
+// It is the engine every split mode writes its files through: it names them, hands them to the sink,
+// and stops and cleans up on the first failure. Writes already running when that happens can fail as well,
+// and every one of those failures is reported.
+// It keeps track of where in the input each chunk starts to describe it in a PartInfo.
+type chunkWriter struct {
+	parent   context.Context
+	ctx      context.Context
+	cancel   context.CancelFunc
+	out      Output
+	suffixes *Suffixes
+	idx      int
+	offset   int64
+	line     int64
+	sem      chan struct{}
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	errs     []error
+}
+
+// newChunkWriter is a function that creates a chunkWriter naming its files as out describes.
+// provided are the fields of a name template the split mode can fill in (see Output.prepare).
+func newChunkWriter(out Output, provided ...string) (*chunkWriter, error) {
+	if err := out.prepare(provided...); err != nil {
+		return nil, err
+	}
+	suffixes, err := out.suffixes()
+	if err != nil {
+		return nil, err
+	}
+
+	const maxGoroutines = 10
+	ctx, cancel := context.WithCancel(out.context())
+	return &chunkWriter{
+		parent:   out.context(),
+		ctx:      ctx,
+		cancel:   cancel,
+		out:      out,
+		suffixes: suffixes,
+		line:     1,
+		sem:      make(chan struct{}, maxGoroutines),
+	}, nil
+}
+
+// write hands the content of the next file to the next free goroutine, blocking until one is available.
+// Once any write has failed it stops accepting chunks and returns that failure.
+func (w *chunkWriter) write(content []byte) error {
+	suffix, ok := w.suffixes.Suffix(w.idx)
+	if !ok {
+		return errorOf(ErrSuffixExhausted, "error: too many files")
+	}
+
+	newlines := int64(bytes.Count(content, []byte("\n")))
+	part := PartInfo{Index: w.idx, Suffix: suffix, Offset: w.offset, StartLine: w.line, EndLine: w.line + newlines}
+	if bytes.HasSuffix(content, []byte("\n")) {
+		part.EndLine--
+	}
+	w.idx++
+	w.offset += int64(len(content))
+	w.line += newlines
+
+	return w.writePart(bytes.NewReader(content), part)
+}
+
+// writePart hands the content of the part, which the split mode has described itself, to the next free goroutine,
+// blocking until one is available.
+func (w *chunkWriter) writePart(content io.Reader, part PartInfo) error {
+	if w.ctx.Err() != nil {
+		return w.wait()
+	}
+	select {
+	case w.sem <- struct{}{}:
+	case <-w.ctx.Done():
+		return w.wait()
+	}
+
+	w.out.announce(w.out.name(part))
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() { <-w.sem }()
+
+		err := w.out.writeToFile(w.ctx, content, part)
+		if err != nil {
+			w.fail(err)
+		}
+	}()
+	return nil
+}
+
+// fail records the failure of a write and stops the others.
+// A write that only failed because an earlier failure or the caller cancelled the split is not recorded itself.
+func (w *chunkWriter) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return
+	}
+	w.errs = append(w.errs, err)
+	w.cancel()
+}
+
+// wait blocks until every started write has finished and returns their failures joined, if any.
+// The split is also a failure when the context of the Output was cancelled, such as by an interrupt.
+func (w *chunkWriter) wait() error {
+	w.wg.Wait()
+	w.cancel()
+
+	if len(w.errs) > 0 {
+		return w.out.rollback(joinPartErrors(w.errs))
+	}
+	if err := w.parent.Err(); err != nil {
+		return w.out.rollback(fmt.Errorf("error: %w", err))
+	}
+	return nil
+}
+
+// abort waits for the started writes and returns err after removing the files written so far (see Output.rollback).
+func (w *chunkWriter) abort(err error) error {
+	w.wg.Wait()
+	w.cancel()
+	return w.out.rollback(err)
+}
+
+// splitByFileCountsMultithread is a function that splits a file to the number of files using goroutines.
+// Inputs that are not regular files, such as pipes, are spooled to a temporary file first to learn their size.
+func splitByFileCountsMultithread(r io.Reader, fileCount int, out Output) error {
+	return splitIntoChunks(r, fileCount, false, out)
+}
+
+// splitByLineChunksMultithread is a function that splits a file to the number of files like
+// splitByFileCountsMultithread, but moves every boundary forward to the next newline so that no line is broken.
+func splitByLineChunksMultithread(r io.Reader, fileCount int, out Output) error {
+	return splitIntoChunks(r, fileCount, true, out)
+}
+
+// splitByRoundRobin is a function that deals the lines of a file out to fileCount files in turn:
+// the first line goes to the first file, the second line to the second file and so on.
+// Every file is created when it gets its first line and stays open with a buffered writer until the input is
+// exhausted; the files that get no line are created empty at the end, unless ElideEmpty is set.
+func splitByRoundRobin(r io.Reader, fileCount int, out Output) (err error) {
+	if err := out.prepare(FieldTotal); err != nil {
+		return err
+	}
+	strs, err := out.firstSuffixes(fileCount)
+	if err != nil {
+		return err
+	}
+
+	parts := make([]PartInfo, fileCount)
+	names := make([]string, fileCount)
+	for i := range parts {
+		parts[i] = PartInfo{Index: i, Suffix: strs[i], Total: fileCount}
+		parts[i].Name = out.name(parts[i])
+		names[i] = parts[i].Name
+	}
+	if err := out.checkNotExist(names); err != nil {
+		return err
+	}
+
+	// Files are only created once they get their first line, so with ElideEmpty a file that gets none is never
+	// created at all, which matters when creating it has effects of its own, such as running a filter.
+	files := make([]io.WriteCloser, fileCount)
+	counters := make([]*countingWriter, fileCount)
+	writers := make([]*bufio.Writer, fileCount)
+	finished := 0
+	defer func() {
+		if err != nil {
+			for _, file := range files[finished:] {
+				if file != nil {
+					abortPart(file)
+				}
+			}
+		}
+	}()
+
+	ctx := out.context()
+	create := func(i int) error {
+		out.announce(parts[i].Name)
+		file, err := out.Sink.Create(ctx, parts[i])
+		if err != nil {
+			return &PartError{Index: i, Name: parts[i].Name, Err: err}
+		}
+		files[i] = file
+		counters[i] = &countingWriter{w: file}
+		writers[i] = bufio.NewWriter(counters[i])
+		return nil
+	}
+
+	err = dealLines(r, fileCount, func(i int, piece []byte) error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		if files[i] == nil {
+			if err := create(i); err != nil {
+				return err
+			}
+		}
+		if _, err := writers[i].Write(piece); err != nil {
+			return &PartError{Index: i, Name: parts[i].Name, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return out.rollback(err)
+	}
+	if !out.ElideEmpty {
+		for i, file := range files {
+			if file != nil {
+				continue
+			}
+			if err := create(i); err != nil {
+				return out.rollback(err)
+			}
+		}
+	}
+
+	var errs []error
+	for i, writer := range writers {
+		if writer == nil {
+			continue
+		}
+		if err := writer.Flush(); err != nil {
+			errs = append(errs, &PartError{Index: i, Name: parts[i].Name, Err: fmt.Errorf("error writing to the file: %w", err)})
+		}
+	}
+	if len(errs) > 0 {
+		return out.rollback(joinPartErrors(errs))
+	}
+	for i, file := range files {
+		finished = i + 1
+		// Like GNU split, the names of elided files are skipped rather than given to the next file.
+		if file == nil {
+			continue
+		}
+		if err := file.Close(); err != nil {
+			errs = append(errs, &PartError{Index: i, Name: parts[i].Name, Err: err})
+			continue
+		}
+		out.created.add(parts[i])
+		out.record(parts[i], counters[i], false)
+	}
+	return out.rollback(joinPartErrors(errs))
+}
+
+// writeRoundRobinChunk is a function that writes only the lines splitByRoundRobin would put into
+// the index-th (counting from 1) of the fileCount files to w.
+func writeRoundRobinChunk(ctx context.Context, r io.Reader, fileCount int, index int, w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	err := dealLines(r, fileCount, func(i int, piece []byte) error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		if i != index-1 {
+			return nil
+		}
+		_, err := writer.Write(piece)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing chunk %d: %w", index, err)
+	}
+	return nil
+}
+
+// dealLines is a function that reads r line by line and passes every line to write along with its
+// number modulo fileCount. Long lines are passed in several pieces with the same number.
+func dealLines(r io.Reader, fileCount int, write func(int, []byte) error) error {
+	reader := bufio.NewReader(r)
+	i := 0
+	for {
+		piece, err := reader.ReadSlice('\n')
+		if len(piece) > 0 {
+			if err := write(i, piece); err != nil {
+				return fmt.Errorf("error writing to the file: %w", err)
+			}
+		}
+
+		switch err {
+		case nil:
+			i = (i + 1) % fileCount
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			return nil
+		default:
+			return fmt.Errorf("error: reading file: %w", err)
+		}
+	}
+}
+
+// splitIntoChunks is a function that writes every chunk computed by chunkBoundaries to its own file using goroutines.
+// Each goroutine reads its chunk straight from the file, so the input is never held in memory.
+func splitIntoChunks(r io.Reader, fileCount int, keepLines bool, out Output) error {
+	w, err := newChunkWriter(out, FieldTotal, FieldOffset, FieldStartLine, FieldEndLine)
+	if err != nil {
+		return err
+	}
+	out = w.out
+
+	file, cleanup, err := seekableInput(out.context(), r)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	bounds, err := chunkBoundaries(file, fileCount, keepLines)
+	if err != nil {
+		return err
+	}
+	if out.ElideEmpty {
+		bounds = withoutEmptyChunks(bounds)
+		fileCount = len(bounds) - 1
+		if fileCount == 0 {
+			return nil
+		}
+	}
+
+	strs, err := out.firstSuffixes(fileCount)
+	if err != nil {
+		return err
+	}
+
+	parts := make([]PartInfo, fileCount)
+	for i := range parts {
+		parts[i] = PartInfo{Index: i, Suffix: strs[i], Total: fileCount, Offset: bounds[i]}
+	}
+	// Line numbers take another pass over the input, so they are only counted when the names need them.
+	if out.Template != nil && (out.Template.uses(FieldStartLine) || out.Template.uses(FieldEndLine)) {
+		if err := countChunkLines(out.context(), file, bounds, parts); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, fileCount)
+	for i := range names {
+		names[i] = out.name(parts[i])
+	}
+	if err := out.checkNotExist(names); err != nil {
+		return err
+	}
+
+	for i, part := range parts {
+		if err := w.writePart(io.NewSectionReader(file, bounds[i], bounds[i+1]-bounds[i]), part); err != nil {
+			return w.abort(err)
+		}
+	}
+	return w.wait()
+}
+
+// countChunkLines is a function that fills in the numbers of the first and last line of every chunk.
+func countChunkLines(ctx context.Context, file io.ReaderAt, bounds []int64, parts []PartInfo) error {
+	buffer := make([]byte, 64*1024)
+	line := int64(1)
+	for i := range parts {
+		parts[i].StartLine = line
+
+		section := contextReader{ctx: ctx, r: io.NewSectionReader(file, bounds[i], bounds[i+1]-bounds[i])}
+		var newlines int64
+		var last byte
+		for {
+			n, err := section.Read(buffer)
+			if n > 0 {
+				newlines += int64(bytes.Count(buffer[:n], []byte("\n")))
+				last = buffer[n-1]
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("error: reading file: %w", err)
+			}
+		}
+
+		parts[i].EndLine = line + newlines
+		if last == '\n' {
+			parts[i].EndLine--
+		}
+		line += newlines
+	}
+	return nil
+}
+
+// writeChunk is a function that writes only the index-th (counting from 1) of the fileCount chunks that
+// splitByFileCountsMultithread or, with keepLines, splitByLineChunksMultithread would create to w.
+// Only the byte range of that chunk is read from the input.
+func writeChunk(ctx context.Context, r io.Reader, fileCount int, index int, keepLines bool, w io.Writer) error {
+	file, cleanup, err := seekableInput(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	totalSize, err := inputSize(file)
+	if err != nil {
+		return err
+	}
+	start, err := chunkBoundary(file, index-1, fileCount, totalSize, keepLines)
+	if err != nil {
+		return err
+	}
+	end, err := chunkBoundary(file, index, fileCount, totalSize, keepLines)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, contextReader{ctx: ctx, r: io.NewSectionReader(file, start, end-start)})
+	if err != nil {
+		return fmt.Errorf("error: writing chunk %d: %w", index, err)
+	}
+	return nil
+}
+
+// chunkBoundaries is a function that returns fileCount+1 offsets, so that chunk i is the range [bounds[i], bounds[i+1]).
+func chunkBoundaries(file *os.File, fileCount int, keepLines bool) ([]int64, error) {
+	totalSize, err := inputSize(file)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := make([]int64, fileCount+1)
+	for i := 1; i <= fileCount; i++ {
+		bounds[i], err = chunkBoundary(file, i, fileCount, totalSize, keepLines)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bounds, nil
+}
+
+// inputSize is a function that returns the size of the file.
+func inputSize(file *os.File) (int64, error) {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return fileInfo.Size(), nil
+}
+
+// withoutEmptyChunks is a function that drops the empty chunks from bounds, as returned by chunkBoundaries.
+// The chunks that are left still cover the whole input, since the dropped ones had nothing in them.
+func withoutEmptyChunks(bounds []int64) []int64 {
+	kept := bounds[:1]
+	for _, bound := range bounds[1:] {
+		if bound != kept[len(kept)-1] {
+			kept = append(kept, bound)
+		}
+	}
+	return kept
+}
+
+// chunkBoundary is a function that returns the offset at which chunk i starts and chunk i-1 ends.
+// Every chunk has the same size and the last one also takes the remainder, so like GNU split, an input smaller than
+// fileCount bytes leaves all but the last chunk empty. With keepLines, the boundary is moved forward to just past
+// the next newline, which can leave some chunks empty when lines are long.
+func chunkBoundary(file io.ReaderAt, i int, fileCount int, totalSize int64, keepLines bool) (int64, error) {
+	if i == 0 {
+		return 0, nil
+	}
+	if i == fileCount {
+		return totalSize, nil
+	}
+
+	offset := int64(i) * (totalSize / int64(fileCount))
+	if !keepLines || offset == 0 {
+		return offset, nil
+	}
+	return nextLineStart(file, offset, totalSize)
+}
+
+// nextLineStart is a function that returns the offset just past the first newline at or after offset-1,
+// or totalSize when there is none.
+func nextLineStart(file io.ReaderAt, offset int64, totalSize int64) (int64, error) {
+	reader := bufio.NewReader(io.NewSectionReader(file, offset-1, totalSize-offset+1))
+	pos := offset - 1
+	for {
+		line, err := reader.ReadSlice('\n')
+		pos += int64(len(line))
+		switch err {
+		case nil:
+			return pos, nil
+		case io.EOF:
+			return totalSize, nil
+		case bufio.ErrBufferFull:
+			continue
+		default:
+			return 0, fmt.Errorf("error: reading file: %w", err)
+		}
+	}
+}
+
+// seekableInput is a function that returns r as a regular file that can be measured and seeked.
+// Any other reader is copied to a spool file in $TMPDIR, which the returned cleanup function removes.
+// Cancelling ctx stops the copy.
+func seekableInput(ctx context.Context, r io.Reader) (*os.File, func(), error) {
+	if file, ok := r.(*os.File); ok {
+		fileInfo, err := file.Stat()
+		if err == nil && fileInfo.Mode().IsRegular() {
+			return file, func() {}, nil
+		}
+	}
+
+	spool, err := os.CreateTemp("", "split-spool-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error: creating spool file: %w", err)
+	}
+	// Unlinking the open spool file right away means it goes away with the process,
+	// even when split is interrupted. Systems that can't do that remove it in cleanup.
+	unlinked := os.Remove(spool.Name()) == nil
+	cleanup := func() {
+		_ = spool.Close()
+		if !unlinked {
+			_ = os.Remove(spool.Name())
+		}
+	}
+
+	if _, err := io.Copy(spool, contextReader{ctx: ctx, r: r}); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("error: spooling input: %w", err)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("error: spooling input: %w", err)
+	}
+	return spool, cleanup, nil
+}
+
+// splitByBytesMultithread is a function that splits a file by the number of bytes using goroutines (see ByBytes).
+func splitByBytesMultithread(r io.Reader, byteSize int64, out Output) error {
+	return splitStream(r, ByBytes(byteSize), out)
+}
@@ -0,0 +1,909 @@
+package splitter
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// Helper functions
+
+const bigInt = 9223372036854775807
+
+func createTmpFile(content string) *os.File {
+	tmpfile, _ := os.CreateTemp("", "example_tmp")
+	_, _ = tmpfile.WriteString(content)
+	_, _ = tmpfile.Seek(0, 0)
+	return tmpfile
+}
+
+func removeFilesWithPattern(pattern string) {
+	// Need to wait for the file to be created.
+	time.Sleep(200 * time.Millisecond)
+	matches, _ := filepath.Glob(pattern)
+	for _, match := range matches {
+		_ = os.Remove(match)
+	}
+}
+
+func fileNamesWithPattern(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// umask returns the permission bits the process umask removes from new directories.
+func umask() os.FileMode {
+	dir, _ := os.MkdirTemp("", "umask")
+	defer func() { _ = os.Remove(dir) }()
+	probe := filepath.Join(dir, "probe")
+	_ = os.Mkdir(probe, 0o777)
+	defer func() { _ = os.Remove(probe) }()
+	info, _ := os.Stat(probe)
+	return 0o777 &^ info.Mode().Perm()
+}
+
+func concatFiles(names []string) string {
+	var sb strings.Builder
+	for _, name := range names {
+		content, _ := os.ReadFile(name)
+		sb.Write(content)
+	}
+	return sb.String()
+}
+
+func TestSplitByLinesMultithread(t *testing.T) {
+	tmpfile := createTmpFile(
+		`first line
+		second line
+		third line
+		fourth line
+		fifth line
+		sixth line`,
+	)
+
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	_ = splitByLinesMultithread(tmpfile, 2, Output{Prefix: baseFileName.String(), SuffixLen: 2})
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	expected := []string{baseFileName.String() + "aa", baseFileName.String() + "ab", baseFileName.String() + "ac"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestSplitByLinesMultithreadTooLargeFile(t *testing.T) {
+	tmpfile := createTmpFile(
+		`first line
+		second line
+		third line
+		fourth line
+		fifth line
+		sixth line
+		seventh line
+		eighth line
+		ninth line
+		tenth line
+		eleventh line
+		twelfth line
+		thirteenth line
+		fourteenth line
+		fifteenth line
+		sixteenth line
+		seventeenth line
+		eighteenth line
+		nineteenth line
+		twentieth line
+		twenty-first line
+		twenty-second line
+		twenty-third line
+		twenty-fourth line
+		twenty-fifth line
+		twenty-sixth line
+		twenty-seventh line
+		`,
+	)
+
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	err := splitByLinesMultithread(tmpfile, 1, Output{Prefix: baseFileName.String(), SuffixLen: 1})
+
+	expected := fmt.Errorf("error: too many files")
+	if err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestSplitByLinesMultithreadStreamsChunks(t *testing.T) {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	tmpfile := createTmpFile(strings.Join(lines, "\n") + "\n")
+
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	err := splitByLinesMultithread(tmpfile, 3, Output{Prefix: baseFileName.String(), SuffixLen: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	if len(res) != 34 {
+		t.Fatalf("expected %v files, got %v", 34, len(res))
+	}
+	for i, name := range res {
+		end := i*3 + 3
+		if end > len(lines) {
+			end = len(lines)
+		}
+		expected := strings.Join(lines[i*3:end], "\n") + "\n"
+		content, _ := os.ReadFile(name)
+		if string(content) != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, string(content))
+		}
+	}
+}
+
+func TestSplitByLinesMultithreadIsByteExact(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		lineCount int
+		files     int
+	}{
+		{name: "crlf", content: "one\r\ntwo\r\nthree\r\nfour\r\nfive\r\n", lineCount: 2, files: 3},
+		{name: "no trailing newline", content: "one\ntwo\nthree", lineCount: 2, files: 2},
+		{name: "long lines", content: strings.Repeat("a", 3<<20) + "\n" + strings.Repeat("b", 5<<20) + "\nc\n", lineCount: 1, files: 3},
+	}
+
+	for _, tt := range tests {
+		tmpfile := createTmpFile(tt.content)
+		baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+		err := splitByLinesMultithread(tmpfile, tt.lineCount, Output{Prefix: baseFileName.String(), SuffixLen: 2})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+		if len(res) != tt.files {
+			t.Errorf("%s: expected %v files, got %v", tt.name, tt.files, len(res))
+		}
+		if concatFiles(res) != tt.content {
+			t.Errorf("%s: concatenated output differs from the input", tt.name)
+		}
+
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}
+}
+
+func TestSplitByLinesMultithreadWidenSuffix(t *testing.T) {
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	out := Output{Prefix: baseFileName.String(), SuffixLen: 1, WidenSuffix: true}
+	err := splitByLinesMultithread(strings.NewReader(strings.Repeat("line\n", 27)), 1, out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	if len(res) != 27 {
+		t.Fatalf("expected %v files, got %v", 27, len(res))
+	}
+	if res[26] != baseFileName.String()+"zab" {
+		t.Errorf("expected %v, got %v", baseFileName.String()+"zab", res[26])
+	}
+}
+
+func TestSplitByLinesMultithreadNumericSuffixes(t *testing.T) {
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	out := Output{Prefix: baseFileName.String(), SuffixLen: 3, SuffixKind: SuffixNumeric, SuffixStart: 8}
+	err := splitByLinesMultithread(strings.NewReader("1\n2\n3\n"), 1, out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	expected := []string{baseFileName.String() + "008", baseFileName.String() + "009", baseFileName.String() + "010"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestSplitByLinesMultithreadNameTemplate(t *testing.T) {
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	tmpl, _ := ParseNameTemplate("{prefix}{index:02}_{start_line}-{end_line}{ext}")
+	out := Output{Prefix: baseFileName.String(), SuffixLen: 2, AdditionalSuffix: ".txt", Template: tmpl}
+	err := splitByLinesMultithread(strings.NewReader("1\n2\n3\n4\n5"), 2, out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	expected := []string{
+		baseFileName.String() + "00_1-2.txt",
+		baseFileName.String() + "01_3-4.txt",
+		baseFileName.String() + "02_5-5.txt",
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestSplitByLinesMultithreadNameTemplateWithTotal(t *testing.T) {
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	tmpl, _ := ParseNameTemplate("{prefix}{number}-of-{total}")
+	out := Output{Prefix: baseFileName.String(), SuffixLen: 2, Template: tmpl}
+	err := splitByLinesMultithread(strings.NewReader("1\n2\n3\n"), 1, out)
+
+	expected := fmt.Errorf("error: {total} is not available in the name template when splitting this way")
+	if err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	if len(res) != 0 {
+		t.Errorf("expected no files, got %v", res)
+	}
+}
+
+func TestSplitByLinesMultithreadOutputDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "parts")
+
+	out := Output{Dir: dir, DirMode: 0o750, Prefix: "part_", SuffixLen: 2}
+	err := splitByLinesMultithread(strings.NewReader("1\n2\n3\n"), 2, out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(filepath.Join(dir, "*"))
+	expected := []string{filepath.Join(dir, "part_aa"), filepath.Join(dir, "part_ab")}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+
+	info, _ := os.Stat(dir)
+	if info.Mode().Perm() != 0o750&^umask() {
+		t.Errorf("expected mode %v, got %v", os.FileMode(0o750&^umask()), info.Mode().Perm())
+	}
+}
+
+func TestSplitByLinesMultithreadNoClobber(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "xac")
+	_ = os.WriteFile(existing, []byte("previous run\n"), 0o644)
+
+	err := splitByLinesMultithread(strings.NewReader("1\n2\n3\n4\n"), 1, Output{Dir: dir, SuffixLen: 2})
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected an error because %v exists, got %v", existing, err)
+	}
+
+	res, _ := fileNamesWithPattern(filepath.Join(dir, "*"))
+	if !reflect.DeepEqual(res, []string{existing}) {
+		t.Errorf("expected only %v to be left, got %v", existing, res)
+	}
+	content, _ := os.ReadFile(existing)
+	if string(content) != "previous run\n" {
+		t.Errorf("expected %v to be untouched, got %q", existing, string(content))
+	}
+
+	err = splitByLinesMultithread(strings.NewReader("1\n2\n3\n4\n"), 1, Output{Dir: dir, SuffixLen: 2, Force: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(existing)
+	if string(content) != "3\n" {
+		t.Errorf("expected %v to be overwritten, got %q", existing, string(content))
+	}
+}
+
+func TestSplitByLinesMultithreadRemovesPartialOutput(t *testing.T) {
+	dir := t.TempDir()
+	input := func() io.Reader {
+		return io.MultiReader(strings.NewReader("1\n2\n3\n4\n"), iotest.ErrReader(errors.New("read failed")))
+	}
+
+	err := splitByLinesMultithread(input(), 1, Output{Dir: dir, SuffixLen: 2})
+	if err == nil {
+		t.Fatalf("expected an error, got nil")
+	}
+	res, _ := fileNamesWithPattern(filepath.Join(dir, "*"))
+	if len(res) != 0 {
+		t.Errorf("expected no files to be left, got %v", res)
+	}
+
+	err = splitByLinesMultithread(input(), 1, Output{Dir: dir, SuffixLen: 2, KeepPartial: true})
+	if err == nil {
+		t.Fatalf("expected an error, got nil")
+	}
+	res, _ = fileNamesWithPattern(filepath.Join(dir, "*"))
+	if len(res) != 4 {
+		t.Errorf("expected %v files to be kept, got %v", 4, res)
+	}
+}
+
+func TestSplitByLinesMultithreadCancelled(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	r, w := io.Pipe()
+	go func() {
+		_, _ = w.Write([]byte("1\n2\n3\n"))
+		cancel()
+		_, _ = w.Write([]byte("4\n5\n"))
+		_ = w.Close()
+	}()
+
+	err := splitByLinesMultithread(r, 1, Output{ctx: ctx, Dir: dir, SuffixLen: 2})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	res, _ := fileNamesWithPattern(filepath.Join(dir, "*"))
+	hidden, _ := fileNamesWithPattern(filepath.Join(dir, ".*"))
+	if len(res) != 0 || len(hidden) != 0 {
+		t.Errorf("expected no files to be left, got %v and %v", res, hidden)
+	}
+}
+
+func TestSplitByFileCountsMultithreadCancelled(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := splitByFileCountsMultithread(strings.NewReader("one\ntwo\nthree\n"), 3, Output{ctx: ctx, Dir: dir, SuffixLen: 2})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	res, _ := fileNamesWithPattern(filepath.Join(dir, "*"))
+	if len(res) != 0 {
+		t.Errorf("expected no files to be left, got %v", res)
+	}
+}
+
+func TestSplitByLinesMultithreadFromReader(t *testing.T) {
+	input := "one\ntwo\nthree\n"
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	err := splitByLinesMultithread(strings.NewReader(input), 2, Output{Prefix: baseFileName.String(), SuffixLen: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	if concatFiles(res) != input {
+		t.Errorf("expected %q, got %q", input, concatFiles(res))
+	}
+}
+
+func TestSplitByLineBytesMultithread(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "whole lines",
+			content:  "aaa\nbbb\nccc\n",
+			expected: []string{"aaa\nbbb\n", "ccc\n"},
+		},
+		{
+			name:     "exact fit",
+			content:  "123456789\na\n",
+			expected: []string{"123456789\n", "a\n"},
+		},
+		{
+			name:     "long line",
+			content:  "a\n" + strings.Repeat("x", 25) + "\ny\n",
+			expected: []string{"a\n", strings.Repeat("x", 10), strings.Repeat("x", 10), "xxxxx\ny\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+		err := splitByLineBytesMultithread(strings.NewReader(tt.content), 10, Output{Prefix: baseFileName.String(), SuffixLen: 2})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+		var contents []string
+		for _, name := range res {
+			content, _ := os.ReadFile(name)
+			contents = append(contents, string(content))
+		}
+		if !reflect.DeepEqual(contents, tt.expected) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, contents)
+		}
+
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}
+}
+
+func TestSplitByPattern(t *testing.T) {
+	tmpfile := createTmpFile("header\n== one\nfirst body\n== two\nsecond body\nmore body")
+
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	err := splitByPattern(tmpfile, "^==", Output{Prefix: baseFileName.String(), SuffixLen: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	expected := []string{baseFileName.String() + "aa", baseFileName.String() + "ab", baseFileName.String() + "ac"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+
+	content, _ := os.ReadFile(baseFileName.String() + "ac")
+	if string(content) != "== two\nsecond body\nmore body" {
+		t.Errorf("expected %q, got %q", "== two\nsecond body\nmore body", string(content))
+	}
+}
+
+func TestSplitByPatternCRLF(t *testing.T) {
+	input := "a\r\nSTART\r\nb\r\nSTART\r\n"
+	tmpfile := createTmpFile(input)
+
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	err := splitByPattern(tmpfile, "^START$", Output{Prefix: baseFileName.String(), SuffixLen: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	if len(res) != 3 {
+		t.Errorf("expected %v files, got %v", 3, len(res))
+	}
+	if concatFiles(res) != input {
+		t.Errorf("expected %q, got %q", input, concatFiles(res))
+	}
+}
+
+func TestSplitByPatternIllegalRegexp(t *testing.T) {
+	tmpfile := createTmpFile("first line\nsecond line")
+
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+	}()
+
+	err := splitByPattern(tmpfile, "(", Output{Prefix: "x", SuffixLen: 2})
+
+	expected := fmt.Errorf("error: (: illegal regexp")
+	if err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestSplitByFileCountsMultithread(t *testing.T) {
+	tmpfile := createTmpFile(
+		`first line
+		second line
+		third line
+		fourth line
+		fifth line
+		sixth line`,
+	)
+
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	_ = splitByFileCountsMultithread(tmpfile, 2, Output{Prefix: baseFileName.String(), SuffixLen: 2})
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	expected := []string{baseFileName.String() + "aa", baseFileName.String() + "ab"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestSplitByFileCountsMultithreadFromReader(t *testing.T) {
+	input := "one\ntwo\nthree\n"
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	err := splitByFileCountsMultithread(strings.NewReader(input), 2, Output{Prefix: baseFileName.String(), SuffixLen: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	expected := []string{baseFileName.String() + "aa", baseFileName.String() + "ab"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+	if concatFiles(res) != input {
+		t.Errorf("expected %q, got %q", input, concatFiles(res))
+	}
+
+	spools, _ := filepath.Glob(filepath.Join(os.TempDir(), "split-spool-*"))
+	if len(spools) != 0 {
+		t.Errorf("expected the spool file to be removed, found %v", spools)
+	}
+}
+
+func TestSplitByLineChunksMultithread(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "short lines",
+			content:  "one\ntwo\nthree\nfour\nfive\nsix\n",
+			expected: []string{"one\ntwo\nthree\n", "four\n", "five\nsix\n"},
+		},
+		{
+			name:     "long line",
+			content:  "a\n" + strings.Repeat("b", 20) + "\nc\nd\n",
+			expected: []string{"a\n" + strings.Repeat("b", 20) + "\n", "", "c\nd\n"},
+		},
+		{
+			name:     "no trailing newline",
+			content:  "one\ntwo\nthree",
+			expected: []string{"one\n", "two\n", "three"},
+		},
+	}
+
+	for _, tt := range tests {
+		tmpfile := createTmpFile(tt.content)
+		baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+		err := splitByLineChunksMultithread(tmpfile, 3, Output{Prefix: baseFileName.String(), SuffixLen: 2})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+		var contents []string
+		for _, name := range res {
+			content, _ := os.ReadFile(name)
+			contents = append(contents, string(content))
+		}
+		if !reflect.DeepEqual(contents, tt.expected) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, contents)
+		}
+
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}
+}
+
+func TestSplitByFileCountsMultithreadNameTemplate(t *testing.T) {
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	tmpl, _ := ParseNameTemplate("{prefix}-part-{number:02}-of-{total}-{start_line}-{end_line}{ext}")
+	out := Output{Prefix: baseFileName.String(), SuffixLen: 2, AdditionalSuffix: ".sql", Template: tmpl}
+	err := splitByLineChunksMultithread(strings.NewReader("one\ntwo\nthree\nfour\nfive\nsix\n"), 3, out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	expected := []string{
+		baseFileName.String() + "-part-01-of-3-1-3.sql",
+		baseFileName.String() + "-part-02-of-3-4-4.sql",
+		baseFileName.String() + "-part-03-of-3-5-6.sql",
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestSplitByFileCountsMultithreadNoClobber(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "xab")
+	_ = os.WriteFile(existing, []byte("previous run\n"), 0o644)
+
+	err := splitByFileCountsMultithread(strings.NewReader("one\ntwo\nthree\n"), 3, Output{Dir: dir, SuffixLen: 2})
+	expected := fmt.Errorf("error creating file: %s: file already exists, use --force to overwrite it", existing)
+	if err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+
+	res, _ := fileNamesWithPattern(filepath.Join(dir, "*"))
+	if !reflect.DeepEqual(res, []string{existing}) {
+		t.Errorf("expected only %v to be left, got %v", existing, res)
+	}
+}
+
+func TestSplitByFileCountsMultithreadSmallInput(t *testing.T) {
+	tests := []struct {
+		split      func(io.Reader, int, Output) error
+		input      string
+		elideEmpty bool
+		expected   map[string]string
+	}{
+		{splitByFileCountsMultithread, "abc", false, map[string]string{"xaa": "", "xab": "", "xac": "", "xad": "", "xae": "abc"}},
+		{splitByFileCountsMultithread, "abc", true, map[string]string{"xaa": "abc"}},
+		{splitByFileCountsMultithread, "", false, map[string]string{"xaa": "", "xab": "", "xac": "", "xad": "", "xae": ""}},
+		{splitByFileCountsMultithread, "", true, map[string]string{}},
+		{splitByLineChunksMultithread, "a\nb\n", false, map[string]string{"xaa": "", "xab": "", "xac": "", "xad": "", "xae": "a\nb\n"}},
+		{splitByLineChunksMultithread, "a\nb\n", true, map[string]string{"xaa": "a\nb\n"}},
+		{splitByLineChunksMultithread, "a\nbbbbbbbbbb\nc\n", true, map[string]string{"xaa": "a\nbbbbbbbbbb\n", "xab": "c\n"}},
+		{splitByRoundRobin, "1\n2\n", false, map[string]string{"xaa": "1\n", "xab": "2\n", "xac": "", "xad": "", "xae": ""}},
+		{splitByRoundRobin, "1\n2\n", true, map[string]string{"xaa": "1\n", "xab": "2\n"}},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		err := test.split(strings.NewReader(test.input), 5, Output{Dir: dir, SuffixLen: 2, ElideEmpty: test.elideEmpty})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		res := map[string]string{}
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			content, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+			res[entry.Name()] = string(content)
+		}
+		if !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%q with -e=%v: expected %v, got %v", test.input, test.elideEmpty, test.expected, res)
+		}
+	}
+}
+
+func TestSplitByRoundRobin(t *testing.T) {
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	err := splitByRoundRobin(strings.NewReader("1\n2\n3\n4\n5\n6\n7"), 3, Output{Prefix: baseFileName.String(), SuffixLen: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	var contents []string
+	for _, name := range res {
+		content, _ := os.ReadFile(name)
+		contents = append(contents, string(content))
+	}
+	expected := []string{"1\n4\n7", "2\n5\n", "3\n6\n"}
+	if !reflect.DeepEqual(contents, expected) {
+		t.Errorf("expected %q, got %q", expected, contents)
+	}
+}
+
+func TestWriteRoundRobinChunk(t *testing.T) {
+	var buf bytes.Buffer
+	longLine := strings.Repeat("x", 10000)
+
+	err := writeRoundRobinChunk(context.Background(), strings.NewReader("1\n"+longLine+"\n3\n4\n"), 2, 2, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := longLine + "\n4\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWriteChunk(t *testing.T) {
+	content := "a\n" + strings.Repeat("b", 20) + "\nc\nd\n"
+	tests := []struct {
+		keepLines bool
+		expected  []string
+	}{
+		{keepLines: false, expected: []string{content[:9], content[9:18], content[18:]}},
+		{keepLines: true, expected: []string{"a\n" + strings.Repeat("b", 20) + "\n", "", "c\nd\n"}},
+	}
+
+	tmpfile := createTmpFile(content)
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+	}()
+
+	for _, tt := range tests {
+		for i, expected := range tt.expected {
+			var buf bytes.Buffer
+			err := writeChunk(context.Background(), tmpfile, 3, i+1, tt.keepLines, &buf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != expected {
+				t.Errorf("chunk %d/3 (keepLines %v): expected %q, got %q", i+1, tt.keepLines, expected, buf.String())
+			}
+		}
+	}
+}
+
+func TestSplitByFileCountsMultithreadTooLargeFile(t *testing.T) {
+	tmpfile := createTmpFile(
+		`first line
+		second line
+		third line
+		fourth line
+		fifth line
+		sixth line
+		seventh line
+		eighth line
+		ninth line
+		tenth line
+		eleventh line
+		twelfth line
+		thirteenth line
+		fourteenth line
+		fifteenth line
+		sixteenth line
+		seventeenth line
+		eighteenth line
+		nineteenth line
+		twentieth line
+		twenty-first line
+		twenty-second line
+		twenty-third line
+		twenty-fourth line
+		twenty-fifth line
+		twenty-sixth line
+		twenty-seventh line
+		`,
+	)
+
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	err := splitByFileCountsMultithread(tmpfile, 27, Output{Prefix: baseFileName.String(), SuffixLen: 1})
+
+	expected := fmt.Errorf("error: too many files")
+	if err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestSplitByFileCountsMultithreadIntoTooManyFiles(t *testing.T) {
+	tmpfile := createTmpFile(
+		`first line
+		second line
+		third line
+		fourth line
+		fifth line
+		sixth line`,
+	)
+
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	_ = splitByBytesMultithread(tmpfile, 2, Output{Prefix: baseFileName.String(), SuffixLen: 2})
+
+	res, _ := fileNamesWithPattern(baseFileName.String() + "*")
+	resLen := len(res)
+	expected := 39
+	if !reflect.DeepEqual(resLen, expected) {
+		t.Errorf("expected %v, got %v", expected, resLen)
+	}
+}
+
+func TestSplitByBytesMultithreadTooLargeFile(t *testing.T) {
+	tmpfile := createTmpFile(
+		`first line
+		second line
+		third line
+		fourth line
+		fifth line
+		sixth line
+		seventh line
+		eighth line
+		ninth line
+		tenth line
+		eleventh line
+		twelfth line
+		thirteenth line
+		fourteenth line
+		fifteenth line
+		sixteenth line
+		seventeenth line
+		eighteenth line
+		nineteenth line
+		twentieth line
+		twenty-first line
+		twenty-second line
+		twenty-third line
+		twenty-fourth line
+		twenty-fifth line
+		twenty-sixth line
+		twenty-seventh line
+		`,
+	)
+	baseFileName, _ := rand.Int(rand.Reader, big.NewInt(bigInt))
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+		removeFilesWithPattern(baseFileName.String() + "*")
+	}()
+
+	err := splitByBytesMultithread(tmpfile, 1, Output{Prefix: baseFileName.String(), SuffixLen: 1})
+
+	expected := fmt.Errorf("error: too many files")
+	if err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
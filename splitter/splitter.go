@@ -0,0 +1,123 @@
+// Package splitter splits an input into files, like the split command.
+//
+// Split reads the input once and writes the split files with a bounded number of goroutines.
+// Options chooses how the input is divided and, through Output, how the files are named and written.
+package splitter
+
+import (
+	"context"
+	"io"
+)
+
+// ChunkMode is how Options.Chunks divides the input into files.
+type ChunkMode int
+
+const (
+	// ChunkBytes divides the input into files of equal size ("-n N").
+	ChunkBytes ChunkMode = iota
+	// ChunkLines divides the input into files of about equal size without breaking lines ("-n l/N").
+	ChunkLines
+	// ChunkRoundRobin deals the lines of the input out to the files in turn ("-n r/N").
+	ChunkRoundRobin
+)
+
+// Options is how Split divides the input. Exactly one of Lines, Chunks, Bytes, LineBytes, Pattern and Strategy is set;
+// Split fails with ErrNoMode when none is and with ErrInvalidOptions when more than one is.
+type Options struct {
+	// Lines is the number of lines per split file (-l).
+	Lines int
+	// Chunks is the number of files to split the input into (-n), divided as ChunkMode says.
+	// Like GNU split, this is always exactly Chunks files, some of which can be empty unless ElideEmpty is set.
+	Chunks    int
+	ChunkMode ChunkMode
+	// ChunkIndex, when not 0, is the single chunk (counting from 1) to write to ChunkOut instead of
+	// writing every chunk to its own file. ChunkOut must then be set.
+	ChunkIndex int
+	ChunkOut   io.Writer
+	// Bytes is the number of bytes per split file (-b).
+	Bytes int64
+	// LineBytes is the maximum number of bytes of whole lines per split file (-C).
+	LineBytes int64
+	// Pattern is a regular expression; every line matching it starts a new split file (-p).
+	Pattern string
+	// Strategy decides where the split files end, for ways of splitting this package doesn't know (see NewStrategy).
+	Strategy Strategy
+	// CollectResults makes Split describe every split file in its Result. It is off by default, since a split into
+	// millions of files would otherwise keep a PartResult for each of them.
+	CollectResults bool
+
+	// Output is how the split files are named and written.
+	Output
+}
+
+// Split is a function that splits the input from r as opts describes.
+// Cancelling ctx, or its deadline passing, stops the split between reads and writes in every mode. It also
+// interrupts a read of r that is waiting for input when r is an *os.File, such as a pipe, or has a SetReadDeadline
+// method, like a net.Conn; Split leaves r without a read deadline when it returns. The caller of any other reader
+// that can block has to close it to interrupt such a read.
+// The split then fails with an error matching ctx.Err() and removes the files it has written unless KeepPartial is set.
+// Errors match the Err variables of this package, fs.ErrExist or ctx.Err() with errors.Is, and the failure of a
+// split file is a *PartError; when several split files fail, their errors are joined with errors.Join.
+// With CollectResults, the Result lists the split files written, in order; when the split fails, those are the files written before
+// the failure, which only still exist with KeepPartial.
+func Split(ctx context.Context, r io.Reader, opts Options) (Result, error) {
+	out := opts.Output
+	out.ctx = ctx
+	if opts.CollectResults {
+		out.results = &results{}
+	}
+	ctx = out.context()
+	r, release := newCancelableReader(ctx, r)
+	defer release()
+
+	if opts.Lines < 0 || opts.Chunks < 0 || opts.Bytes < 0 || opts.LineBytes < 0 {
+		return Result{}, errorOf(ErrInvalidCount, "error: line, file and byte counts must not be negative")
+	}
+	if opts.ChunkIndex < 0 || opts.ChunkIndex > opts.Chunks {
+		return Result{}, errorOf(ErrInvalidCount, "error: %d: chunk index must be between 1 and %d", opts.ChunkIndex, opts.Chunks)
+	}
+	if opts.modes() > 1 {
+		return Result{}, errorOf(ErrInvalidOptions, "error: only one of lines, chunks, bytes, line bytes, pattern and strategy can be given")
+	}
+	if opts.ChunkIndex > 0 && opts.ChunkOut == nil {
+		return Result{}, errorOf(ErrInvalidOptions, "error: a chunk index needs a writer to write the chunk to")
+	}
+
+	var err error
+	switch {
+	case opts.Lines > 0:
+		err = splitByLinesMultithread(r, opts.Lines, out)
+	case opts.Chunks > 0 && opts.ChunkIndex > 0 && opts.ChunkMode == ChunkRoundRobin:
+		err = writeRoundRobinChunk(ctx, r, opts.Chunks, opts.ChunkIndex, opts.ChunkOut)
+	case opts.Chunks > 0 && opts.ChunkIndex > 0:
+		err = writeChunk(ctx, r, opts.Chunks, opts.ChunkIndex, opts.ChunkMode == ChunkLines, opts.ChunkOut)
+	case opts.Chunks > 0 && opts.ChunkMode == ChunkRoundRobin:
+		err = splitByRoundRobin(r, opts.Chunks, out)
+	case opts.Chunks > 0 && opts.ChunkMode == ChunkLines:
+		err = splitByLineChunksMultithread(r, opts.Chunks, out)
+	case opts.Chunks > 0:
+		err = splitByFileCountsMultithread(r, opts.Chunks, out)
+	case opts.Bytes > 0:
+		err = splitByBytesMultithread(r, opts.Bytes, out)
+	case opts.LineBytes > 0:
+		err = splitByLineBytesMultithread(r, opts.LineBytes, out)
+	case opts.Pattern != "":
+		err = splitByPattern(r, opts.Pattern, out)
+	case opts.Strategy != nil:
+		err = splitStream(r, opts.Strategy, out)
+	default:
+		return Result{}, errorOf(ErrNoMode, "error: no way of splitting is given")
+	}
+	return out.results.result(), err
+}
+
+// modes is a method that returns how many ways of splitting the options give.
+func (opts Options) modes() int {
+	count := 0
+	for _, set := range []bool{opts.Lines > 0, opts.Chunks > 0, opts.Bytes > 0, opts.LineBytes > 0, opts.Pattern != "", opts.Strategy != nil} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,168 @@
+package splitter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	dir := t.TempDir()
+
+	res, err := Split(context.Background(), strings.NewReader("1\n2\n3\n"), Options{Lines: 2, CollectResults: true, Output: Output{Dir: dir, Prefix: "part-", SuffixLen: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := []string{}
+	for _, part := range res.Parts {
+		names = append(names, part.Name)
+	}
+	expected := []string{filepath.Join(dir, "part-aa"), filepath.Join(dir, "part-ab")}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	content, _ := os.ReadFile(expected[1])
+	if string(content) != "3\n" {
+		t.Errorf("expected %q, got %q", "3\n", string(content))
+	}
+}
+
+func TestSplitSingleChunk(t *testing.T) {
+	var chunk bytes.Buffer
+
+	res, err := Split(context.Background(), strings.NewReader("1\n2\n3\n4\n"), Options{Chunks: 2, ChunkMode: ChunkLines, ChunkIndex: 2, ChunkOut: &chunk})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if chunk.String() != "3\n4\n" {
+		t.Errorf("expected %q, got %q", "3\n4\n", chunk.String())
+	}
+	if len(res.Parts) != 0 {
+		t.Errorf("expected no split files, got %v", res.Parts)
+	}
+}
+
+func TestSplitWithoutMode(t *testing.T) {
+	_, err := Split(context.Background(), strings.NewReader("1\n"), Options{})
+	if err == nil || err.Error() != "error: no way of splitting is given" {
+		t.Errorf("expected %v, got %v", "error: no way of splitting is given", err)
+	}
+}
+
+// endlessReader is a reader that never ends, giving a line at a time.
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return copy(p, "1\n"), nil
+}
+
+func TestSplitDeadline(t *testing.T) {
+	tests := []Options{
+		{Lines: 1},
+		{Bytes: 3},
+		{LineBytes: 3},
+		{Pattern: "1"},
+		{Strategy: ByLines(1)},
+		{Chunks: 2},
+		{Chunks: 2, ChunkMode: ChunkLines},
+		{Chunks: 2, ChunkMode: ChunkRoundRobin},
+		{Chunks: 2, ChunkIndex: 1, ChunkOut: io.Discard},
+		{Chunks: 2, ChunkMode: ChunkRoundRobin, ChunkIndex: 1, ChunkOut: io.Discard},
+	}
+
+	for _, opts := range tests {
+		dir := t.TempDir()
+		opts.Output = Output{Dir: dir, SuffixLen: 5}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		_, err := Split(ctx, endlessReader{}, opts)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%+v: expected %v, got %v", opts, context.DeadlineExceeded, err)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 0 {
+			t.Errorf("%+v: expected no files to be left, got %v", opts, entries)
+		}
+	}
+}
+
+func TestSplitDefaultSuffixLen(t *testing.T) {
+	sink := &MemorySink{}
+
+	_, err := Split(context.Background(), strings.NewReader("1\n2\n"), Options{Lines: 1, Output: Output{Sink: sink}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string][]byte{"xaa": []byte("1\n"), "xab": []byte("2\n")}
+	if !reflect.DeepEqual(sink.Files(), expected) {
+		t.Errorf("expected %q, got %q", expected, sink.Files())
+	}
+}
+
+func TestSplitDeadlineWhileReadIsBlocked(t *testing.T) {
+	tests := []Options{
+		{Lines: 1},
+		{Chunks: 2},
+		{Chunks: 2, ChunkMode: ChunkRoundRobin},
+		{Chunks: 2, ChunkIndex: 1, ChunkOut: io.Discard},
+		{Chunks: 2, ChunkMode: ChunkRoundRobin, ChunkIndex: 1, ChunkOut: io.Discard},
+	}
+
+	for _, opts := range tests {
+		dir := t.TempDir()
+		opts.Output = Output{Dir: dir}
+		// The input stays open without anything more to read, like an idle pipe.
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, _ = w.Write([]byte("a\nb\nc\n"))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		start := time.Now()
+		_, err = Split(ctx, r, opts)
+		elapsed := time.Since(start)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%+v: expected %v, got %v", opts, context.DeadlineExceeded, err)
+		}
+		if elapsed > time.Second {
+			t.Errorf("%+v: expected the split to stop at the deadline, took %v", opts, elapsed)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 0 {
+			t.Errorf("%+v: expected no files to be left, got %v", opts, entries)
+		}
+
+		// Nothing goes on reading the pipe after Split has returned, and it has no deadline left.
+		_, _ = w.Write([]byte("d\n"))
+		buf := make([]byte, 2)
+		if _, err := io.ReadFull(r, buf); err != nil || string(buf) != "d\n" {
+			t.Errorf("%+v: expected %q, got %q and %v", opts, "d\n", buf, err)
+		}
+		_ = r.Close()
+		_ = w.Close()
+	}
+}
+
+func TestSplitWithoutCollectResults(t *testing.T) {
+	res, err := Split(context.Background(), strings.NewReader("1\n2\n"), Options{Lines: 1, Output: Output{Sink: &MemorySink{}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Parts != nil {
+		t.Errorf("expected no split files to be described, got %v", res.Parts)
+	}
+}
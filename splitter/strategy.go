@@ -0,0 +1,282 @@
+package splitter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Strategy decides where each split file ends while Split reads the input, like a bufio.SplitFunc.
+//
+// Cut is given the input that follows what it has already taken, and returns how many bytes of data it takes
+// into the current split file and whether that file ends right after them. When it takes less than all of data
+// without ending the file, it needs more input to decide, and the rest of data is given again, followed by more.
+// atEOF tells that data is the end of the input; whatever is left when the input ends goes into the last file.
+//
+// Cut is called with the input in order and keeps whatever state it needs, so a Strategy is used for one split only.
+// It must not end a split file before taking any byte of it, and advance must be between 0 and len(data);
+// Split fails with ErrEmptyPart, ErrNegativeAdvance or ErrAdvanceTooFar otherwise.
+type Strategy interface {
+	Cut(data []byte, atEOF bool) (advance int, cut bool, err error)
+}
+
+// ByLines is a function that returns a Strategy putting lineCount lines into every split file.
+func ByLines(lineCount int) Strategy {
+	return &linesStrategy{lineCount: lineCount}
+}
+
+// linesStrategy ends a split file after every lineCount lines.
+type linesStrategy struct {
+	lineCount int
+	lines     int
+}
+
+// Cut is a method that ends the split file after the lineCount-th newline.
+func (s *linesStrategy) Cut(data []byte, atEOF bool) (int, bool, error) {
+	pos := 0
+	for {
+		i := bytes.IndexByte(data[pos:], '\n')
+		if i < 0 {
+			return len(data), false, nil
+		}
+		pos += i + 1
+		s.lines++
+		if s.lines == s.lineCount {
+			s.lines = 0
+			return pos, true, nil
+		}
+	}
+}
+
+// ByBytes is a function that returns a Strategy putting byteSize bytes into every split file.
+func ByBytes(byteSize int64) Strategy {
+	return &bytesStrategy{byteSize: byteSize}
+}
+
+// bytesStrategy ends a split file after every byteSize bytes.
+type bytesStrategy struct {
+	byteSize int64
+	size     int64
+}
+
+// Cut is a method that ends the split file once it has byteSize bytes.
+func (s *bytesStrategy) Cut(data []byte, atEOF bool) (int, bool, error) {
+	room := s.byteSize - s.size
+	if int64(len(data)) < room {
+		s.size += int64(len(data))
+		return len(data), false, nil
+	}
+	s.size = 0
+	return int(room), true, nil
+}
+
+// ByLineBytes is a function that returns a Strategy putting as many whole lines as fit in lineBytes bytes into
+// every split file. Only a line longer than lineBytes is broken across files.
+func ByLineBytes(lineBytes int64) Strategy {
+	return &lineBytesStrategy{lineBytes: lineBytes}
+}
+
+// lineBytesStrategy ends a split file before the line that would make it longer than lineBytes.
+type lineBytesStrategy struct {
+	lineBytes int64
+	size      int64
+}
+
+// Cut is a method that takes whole lines while they fit, and waits for the rest of a line to know whether it does.
+func (s *lineBytesStrategy) Cut(data []byte, atEOF bool) (int, bool, error) {
+	pos := 0
+	for pos < len(data) {
+		length := len(data) - pos
+		complete := atEOF
+		if i := bytes.IndexByte(data[pos:], '\n'); i >= 0 {
+			length, complete = i+1, true
+		}
+
+		switch {
+		case s.size+int64(length) <= s.lineBytes && complete:
+			s.size += int64(length)
+			pos += length
+		case s.size+int64(length) <= s.lineBytes:
+			return pos, false, nil
+		case s.size > 0:
+			s.size = 0
+			return pos, true, nil
+		default:
+			// The line doesn't fit even in a file of its own.
+			return pos + int(s.lineBytes), true, nil
+		}
+	}
+	return pos, false, nil
+}
+
+// ByPattern is a function that returns a Strategy starting a new split file at every line matching the regular
+// expression. The pattern is matched against each line without its line ending.
+func ByPattern(pattern string) (Strategy, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errorOf(ErrInvalidPattern, "error: %s: illegal regexp", pattern)
+	}
+	return &patternStrategy{re: re}, nil
+}
+
+// patternStrategy ends a split file before every line matching re, unless the file would be empty.
+type patternStrategy struct {
+	re     *regexp.Regexp
+	inPart bool
+}
+
+// Cut is a method that matches every whole line, and waits for the rest of a line to match it.
+func (s *patternStrategy) Cut(data []byte, atEOF bool) (int, bool, error) {
+	pos := 0
+	for pos < len(data) {
+		line := data[pos:]
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i+1]
+		} else if !atEOF {
+			return pos, false, nil
+		}
+
+		if s.inPart && s.re.Match(dropLineEnding(line)) {
+			s.inPart = false
+			return pos, true, nil
+		}
+		s.inPart = true
+		pos += len(line)
+	}
+	return pos, false, nil
+}
+
+// dropLineEnding is a function that strips a trailing "\n" or "\r\n" from the line.
+func dropLineEnding(line []byte) []byte {
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r"))
+}
+
+// strategies are the registered ways of making a Strategy by name.
+var strategies = struct {
+	sync.RWMutex
+	byName map[string]func(arg string) (Strategy, error)
+}{byName: map[string]func(string) (Strategy, error){
+	"lines": func(arg string) (Strategy, error) {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			return nil, errorOf(ErrInvalidCount, "error: %s: illegal line count", arg)
+		}
+		return ByLines(n), nil
+	},
+	"bytes": func(arg string) (Strategy, error) {
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, errorOf(ErrInvalidCount, "error: %s: illegal byte count", arg)
+		}
+		return ByBytes(n), nil
+	},
+	"line-bytes": func(arg string) (Strategy, error) {
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, errorOf(ErrInvalidCount, "error: %s: illegal line byte count", arg)
+		}
+		return ByLineBytes(n), nil
+	},
+	"pattern": ByPattern,
+}}
+
+// RegisterStrategy is a function that makes a Strategy available to NewStrategy by name,
+// replacing any Strategy registered before under the same name. newStrategy is called for every split,
+// with an argument such as a count, so that every split gets a Strategy of its own.
+func RegisterStrategy(name string, newStrategy func(arg string) (Strategy, error)) {
+	strategies.Lock()
+	defer strategies.Unlock()
+	strategies.byName[name] = newStrategy
+}
+
+// NewStrategy is a function that makes a new Strategy registered by name, such as "lines", "bytes", "line-bytes"
+// and "pattern", with the argument.
+func NewStrategy(name string, arg string) (Strategy, error) {
+	strategies.RLock()
+	newStrategy, ok := strategies.byName[name]
+	strategies.RUnlock()
+	if !ok {
+		return nil, errorOf(ErrUnknownStrategy, "error: %s: unknown strategy", name)
+	}
+	return newStrategy(arg)
+}
+
+// Strategies is a function that returns the names of the registered strategies in order.
+func Strategies() []string {
+	strategies.RLock()
+	defer strategies.RUnlock()
+	names := make([]string, 0, len(strategies.byName))
+	for name := range strategies.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// splitStream is a function that splits the input into the split files the strategy decides on, reading it once.
+// Each split file is handed to a chunkWriter as soon as it ends, so at most one file per writer is held in memory.
+// The bytes are passed through untouched, so concatenating the split files reproduces the input exactly.
+func splitStream(r io.Reader, strategy Strategy, out Output) error {
+	w, err := newChunkWriter(out, FieldOffset, FieldStartLine, FieldEndLine)
+	if err != nil {
+		return err
+	}
+
+	buffer := make([]byte, 64*1024)
+	var pending []byte
+	var current []byte
+	for {
+		// A cancelled split stops between reads; wait reports why and removes the files written so far.
+		if w.ctx.Err() != nil {
+			return w.wait()
+		}
+		n, readErr := r.Read(buffer)
+		pending = append(pending, buffer[:n]...)
+		atEOF := readErr == io.EOF
+		if readErr != nil && !atEOF {
+			return w.abort(fmt.Errorf("error: reading file: %w", readErr))
+		}
+
+		for len(pending) > 0 {
+			advance, cut, err := strategy.Cut(pending, atEOF)
+			if err != nil {
+				return w.abort(err)
+			}
+			if advance < 0 {
+				return w.abort(errorOf(ErrNegativeAdvance, "error: the strategy took %d bytes", advance))
+			}
+			if advance > len(pending) {
+				return w.abort(errorOf(ErrAdvanceTooFar, "error: the strategy took %d bytes of %d", advance, len(pending)))
+			}
+			current = append(current, pending[:advance]...)
+			pending = pending[advance:]
+			if !cut {
+				break
+			}
+			if len(current) == 0 {
+				return w.abort(errorOf(ErrEmptyPart, "error: the strategy ended a split file before it had any content"))
+			}
+			if err := w.write(current); err != nil {
+				return w.abort(err)
+			}
+			current = nil
+		}
+
+		if atEOF {
+			break
+		}
+	}
+	current = append(current, pending...)
+	if len(current) > 0 {
+		if err := w.write(current); err != nil {
+			return w.abort(err)
+		}
+	}
+
+	return w.wait()
+}
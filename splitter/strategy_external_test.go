@@ -0,0 +1,52 @@
+package splitter_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"split/splitter"
+)
+
+// markerStrategy ends a split file after every marker, the way a strategy for a record format would.
+type markerStrategy struct {
+	marker []byte
+}
+
+func (s *markerStrategy) Cut(data []byte, atEOF bool) (int, bool, error) {
+	if i := bytes.Index(data, s.marker); i >= 0 {
+		return i + len(s.marker), true, nil
+	}
+	if atEOF {
+		return len(data), false, nil
+	}
+	// Keep the end that could be the start of a marker.
+	keep := len(s.marker) - 1
+	if keep > len(data) {
+		keep = len(data)
+	}
+	return len(data) - keep, false, nil
+}
+
+func TestRegisterStrategy(t *testing.T) {
+	splitter.RegisterStrategy("marker", func(arg string) (splitter.Strategy, error) {
+		return &markerStrategy{marker: []byte(arg)}, nil
+	})
+
+	strategy, err := splitter.NewStrategy("marker", "SYNC")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sink := &splitter.MemorySink{}
+	_, err = splitter.Split(context.Background(), strings.NewReader("aaSYNCbbbSYNCc"), splitter.Options{Strategy: strategy, Output: splitter.Output{Sink: sink, SuffixLen: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string][]byte{"xaa": []byte("aaSYNC"), "xab": []byte("bbbSYNC"), "xac": []byte("c")}
+	if !reflect.DeepEqual(sink.Files(), expected) {
+		t.Errorf("expected %q, got %q", expected, sink.Files())
+	}
+}
@@ -0,0 +1,139 @@
+package splitter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStrategiesWithShortReads(t *testing.T) {
+	input := "one\ntwo\r\nthree is long\n#four\nfive"
+	pattern, _ := ByPattern("^#")
+	tests := []struct {
+		strategy Strategy
+		expected []string
+	}{
+		{ByLines(2), []string{"one\ntwo\r\n", "three is long\n#four\n", "five"}},
+		{ByBytes(10), []string{"one\ntwo\r\nt", "hree is lo", "ng\n#four\nf", "ive"}},
+		{ByLineBytes(10), []string{"one\ntwo\r\n", "three is l", "ong\n#four\n", "five"}},
+		{pattern, []string{"one\ntwo\r\nthree is long\n", "#four\nfive"}},
+	}
+
+	for _, test := range tests {
+		sink := &MemorySink{}
+		res, err := Split(context.Background(), iotest.OneByteReader(strings.NewReader(input)), Options{Strategy: test.strategy, CollectResults: true, Output: Output{Sink: sink, SuffixLen: 2}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		files := sink.Files()
+		contents := []string{}
+		for _, part := range res.Parts {
+			contents = append(contents, string(files[part.Name]))
+		}
+		if !reflect.DeepEqual(contents, test.expected) {
+			t.Errorf("%T: expected %q, got %q", test.strategy, test.expected, contents)
+		}
+	}
+}
+
+// emptyCutStrategy is a Strategy that wrongly ends every split file before it has any content.
+type emptyCutStrategy struct{}
+
+func (emptyCutStrategy) Cut(data []byte, atEOF bool) (int, bool, error) {
+	return 0, true, nil
+}
+
+func TestStrategyEndingEmptyFile(t *testing.T) {
+	_, err := Split(context.Background(), strings.NewReader("1\n"), Options{Strategy: emptyCutStrategy{}, Output: Output{Sink: &MemorySink{}, SuffixLen: 2}})
+	expected := "error: the strategy ended a split file before it had any content"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
+
+// failingStrategy is a Strategy that fails once it sees the input.
+type failingStrategy struct{}
+
+func (failingStrategy) Cut(data []byte, atEOF bool) (int, bool, error) {
+	return 0, false, errors.New("bad input")
+}
+
+// advanceStrategy is a Strategy that takes advance bytes whatever it is given.
+type advanceStrategy struct {
+	advance int
+}
+
+func (s advanceStrategy) Cut(data []byte, atEOF bool) (int, bool, error) {
+	return s.advance, false, nil
+}
+
+func TestStrategyBadAdvance(t *testing.T) {
+	tests := []struct {
+		advance  int
+		expected error
+	}{
+		{advance: -1, expected: ErrNegativeAdvance},
+		{advance: 3, expected: ErrAdvanceTooFar},
+	}
+
+	for _, test := range tests {
+		_, err := Split(context.Background(), strings.NewReader("1\n"), Options{Strategy: advanceStrategy{advance: test.advance}, Output: Output{Sink: &MemorySink{}}})
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%d: expected %v, got %v", test.advance, test.expected, err)
+		}
+	}
+}
+
+func TestStrategyError(t *testing.T) {
+	_, err := Split(context.Background(), strings.NewReader("1\n"), Options{Strategy: failingStrategy{}, Output: Output{Sink: &MemorySink{}, SuffixLen: 2}})
+	if err == nil || err.Error() != "bad input" {
+		t.Errorf("expected %v, got %v", "bad input", err)
+	}
+}
+
+func TestNewStrategy(t *testing.T) {
+	strategy, err := NewStrategy("lines", "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sink := &MemorySink{}
+	_, err = Split(context.Background(), strings.NewReader("1\n2\n"), Options{Strategy: strategy, Output: Output{Sink: sink, SuffixLen: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sink.Files()) != 2 {
+		t.Errorf("expected %v files, got %v", 2, len(sink.Files()))
+	}
+
+	_, err = NewStrategy("avro", "")
+	if err == nil || err.Error() != "error: avro: unknown strategy" {
+		t.Errorf("expected %v, got %v", "error: avro: unknown strategy", err)
+	}
+	_, err = NewStrategy("lines", "0")
+	if err == nil || err.Error() != "error: 0: illegal line count" {
+		t.Errorf("expected %v, got %v", "error: 0: illegal line count", err)
+	}
+}
+
+func TestByBytesKeepsInputExact(t *testing.T) {
+	input := bytes.Repeat([]byte("0123456789\n"), 10000)
+	sink := &MemorySink{}
+	res, err := Split(context.Background(), bytes.NewReader(input), Options{Strategy: ByBytes(4096), CollectResults: true, Output: Output{Sink: sink, WidenSuffix: true, SuffixLen: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var joined []byte
+	files := sink.Files()
+	for _, part := range res.Parts {
+		joined = append(joined, files[part.Name]...)
+	}
+	if !bytes.Equal(joined, input) {
+		t.Errorf("expected the split files to add up to the input")
+	}
+}
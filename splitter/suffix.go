@@ -0,0 +1,113 @@
+package splitter
+
+import (
+	"math"
+)
+
+// SuffixKind is the set of characters the suffixes of the split files are made of.
+type SuffixKind int
+
+const (
+	// SuffixAlphabetic makes suffixes of lowercase letters: "aa", "ab", ...
+	SuffixAlphabetic SuffixKind = iota
+	// SuffixNumeric makes suffixes of decimal digits: "00", "01", ...
+	SuffixNumeric
+	// SuffixHex makes suffixes of hexadecimal digits: "00", "01", ..., "0f", "10", ...
+	SuffixHex
+)
+
+// suffixDigits are the characters of each suffix kind in order.
+var suffixDigits = map[SuffixKind]string{
+	SuffixAlphabetic: "abcdefghijklmnopqrstuvwxyz",
+	SuffixNumeric:    "0123456789",
+	SuffixHex:        "0123456789abcdef",
+}
+
+// Suffixes computes the suffix of every split file on demand, so no list of suffixes is ever built.
+//
+// With a fixed length the suffixes run out after the last one ("zz", "99"). When widening is enabled,
+// the last character is instead reserved to grow the suffix the way GNU split does:
+// "aa" ... "yz", then "zaaa" ... "zyzz", then "zzaaaa" and so on, so suffixes never run out.
+type Suffixes struct {
+	digits string
+	length int
+	start  int
+	widen  bool
+}
+
+// DefaultSuffixLen is the length of the suffixes when Output.SuffixLen is 0, which is also the default of split -a.
+const DefaultSuffixLen = 2
+
+// NewSuffixes is a function that creates the suffixes of the given kind and length.
+// The first suffix is the start-th one, which is how numeric and hexadecimal suffixes start at a number.
+func NewSuffixes(kind SuffixKind, length int, start int, widen bool) (*Suffixes, error) {
+	if length <= 0 {
+		return nil, errorOf(ErrInvalidSuffix, "Error: suffix length must be greater than 0")
+	}
+	digits, ok := suffixDigits[kind]
+	if !ok {
+		return nil, errorOf(ErrInvalidSuffix, "error: %d: unknown suffix kind", kind)
+	}
+	if start < 0 {
+		return nil, errorOf(ErrInvalidSuffix, "error: %d: suffix start value must not be negative", start)
+	}
+	s := &Suffixes{digits: digits, length: length, start: start, widen: widen}
+	if !widen && start >= power(len(s.digits), length) {
+		return nil, errorOf(ErrInvalidSuffix, "error: %d: suffix start value is too large for the suffix length", start)
+	}
+	return s, nil
+}
+
+// Suffix is a method that returns the suffix of the i-th (counting from 0) split file.
+// It reports false when the suffixes have run out.
+func (s *Suffixes) Suffix(i int) (string, bool) {
+	radix := len(s.digits)
+	n := s.start + i
+	if n < s.start {
+		return "", false
+	}
+
+	length := s.length
+	widened := 0
+	if s.widen {
+		for {
+			// Suffixes of this length may not start with the last character, which marks a wider one.
+			count := power(radix, length-1)
+			if count <= math.MaxInt/(radix-1) {
+				count *= radix - 1
+			} else {
+				count = math.MaxInt
+			}
+			if n < count {
+				break
+			}
+			n -= count
+			length++
+			widened++
+		}
+	} else if n >= power(radix, length) {
+		return "", false
+	}
+
+	suffix := make([]byte, widened+length)
+	for j := 0; j < widened; j++ {
+		suffix[j] = s.digits[radix-1]
+	}
+	for j := len(suffix) - 1; j >= widened; j-- {
+		suffix[j] = s.digits[n%radix]
+		n /= radix
+	}
+	return string(suffix), true
+}
+
+// power is a function that returns base to the power of exp, or math.MaxInt when that does not fit in an int.
+func power(base int, exp int) int {
+	result := 1
+	for i := 0; i < exp; i++ {
+		if result > math.MaxInt/base {
+			return math.MaxInt
+		}
+		result *= base
+	}
+	return result
+}
@@ -0,0 +1,827 @@
+package splitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// suffixList is a helper that returns the first count suffixes, stopping early when they run out.
+func suffixList(suffixes *Suffixes, count int) []string {
+	var res []string
+	for i := 0; i < count; i++ {
+		suffix, ok := suffixes.Suffix(i)
+		if !ok {
+			break
+		}
+		res = append(res, suffix)
+	}
+	return res
+}
+
+func TestSuffixes(t *testing.T) {
+	suffixes, _ := NewSuffixes(SuffixAlphabetic, 2, 0, false)
+	res := suffixList(suffixes, 1000)
+	expected := []string{
+		"aa",
+		"ab",
+		"ac",
+		"ad",
+		"ae",
+		"af",
+		"ag",
+		"ah",
+		"ai",
+		"aj",
+		"ak",
+		"al",
+		"am",
+		"an",
+		"ao",
+		"ap",
+		"aq",
+		"ar",
+		"as",
+		"at",
+		"au",
+		"av",
+		"aw",
+		"ax",
+		"ay",
+		"az",
+		"ba",
+		"bb",
+		"bc",
+		"bd",
+		"be",
+		"bf",
+		"bg",
+		"bh",
+		"bi",
+		"bj",
+		"bk",
+		"bl",
+		"bm",
+		"bn",
+		"bo",
+		"bp",
+		"bq",
+		"br",
+		"bs",
+		"bt",
+		"bu",
+		"bv",
+		"bw",
+		"bx",
+		"by",
+		"bz",
+		"ca",
+		"cb",
+		"cc",
+		"cd",
+		"ce",
+		"cf",
+		"cg",
+		"ch",
+		"ci",
+		"cj",
+		"ck",
+		"cl",
+		"cm",
+		"cn",
+		"co",
+		"cp",
+		"cq",
+		"cr",
+		"cs",
+		"ct",
+		"cu",
+		"cv",
+		"cw",
+		"cx",
+		"cy",
+		"cz",
+		"da",
+		"db",
+		"dc",
+		"dd",
+		"de",
+		"df",
+		"dg",
+		"dh",
+		"di",
+		"dj",
+		"dk",
+		"dl",
+		"dm",
+		"dn",
+		"do",
+		"dp",
+		"dq",
+		"dr",
+		"ds",
+		"dt",
+		"du",
+		"dv",
+		"dw",
+		"dx",
+		"dy",
+		"dz",
+		"ea",
+		"eb",
+		"ec",
+		"ed",
+		"ee",
+		"ef",
+		"eg",
+		"eh",
+		"ei",
+		"ej",
+		"ek",
+		"el",
+		"em",
+		"en",
+		"eo",
+		"ep",
+		"eq",
+		"er",
+		"es",
+		"et",
+		"eu",
+		"ev",
+		"ew",
+		"ex",
+		"ey",
+		"ez",
+		"fa",
+		"fb",
+		"fc",
+		"fd",
+		"fe",
+		"ff",
+		"fg",
+		"fh",
+		"fi",
+		"fj",
+		"fk",
+		"fl",
+		"fm",
+		"fn",
+		"fo",
+		"fp",
+		"fq",
+		"fr",
+		"fs",
+		"ft",
+		"fu",
+		"fv",
+		"fw",
+		"fx",
+		"fy",
+		"fz",
+		"ga",
+		"gb",
+		"gc",
+		"gd",
+		"ge",
+		"gf",
+		"gg",
+		"gh",
+		"gi",
+		"gj",
+		"gk",
+		"gl",
+		"gm",
+		"gn",
+		"go",
+		"gp",
+		"gq",
+		"gr",
+		"gs",
+		"gt",
+		"gu",
+		"gv",
+		"gw",
+		"gx",
+		"gy",
+		"gz",
+		"ha",
+		"hb",
+		"hc",
+		"hd",
+		"he",
+		"hf",
+		"hg",
+		"hh",
+		"hi",
+		"hj",
+		"hk",
+		"hl",
+		"hm",
+		"hn",
+		"ho",
+		"hp",
+		"hq",
+		"hr",
+		"hs",
+		"ht",
+		"hu",
+		"hv",
+		"hw",
+		"hx",
+		"hy",
+		"hz",
+		"ia",
+		"ib",
+		"ic",
+		"id",
+		"ie",
+		"if",
+		"ig",
+		"ih",
+		"ii",
+		"ij",
+		"ik",
+		"il",
+		"im",
+		"in",
+		"io",
+		"ip",
+		"iq",
+		"ir",
+		"is",
+		"it",
+		"iu",
+		"iv",
+		"iw",
+		"ix",
+		"iy",
+		"iz",
+		"ja",
+		"jb",
+		"jc",
+		"jd",
+		"je",
+		"jf",
+		"jg",
+		"jh",
+		"ji",
+		"jj",
+		"jk",
+		"jl",
+		"jm",
+		"jn",
+		"jo",
+		"jp",
+		"jq",
+		"jr",
+		"js",
+		"jt",
+		"ju",
+		"jv",
+		"jw",
+		"jx",
+		"jy",
+		"jz",
+		"ka",
+		"kb",
+		"kc",
+		"kd",
+		"ke",
+		"kf",
+		"kg",
+		"kh",
+		"ki",
+		"kj",
+		"kk",
+		"kl",
+		"km",
+		"kn",
+		"ko",
+		"kp",
+		"kq",
+		"kr",
+		"ks",
+		"kt",
+		"ku",
+		"kv",
+		"kw",
+		"kx",
+		"ky",
+		"kz",
+		"la",
+		"lb",
+		"lc",
+		"ld",
+		"le",
+		"lf",
+		"lg",
+		"lh",
+		"li",
+		"lj",
+		"lk",
+		"ll",
+		"lm",
+		"ln",
+		"lo",
+		"lp",
+		"lq",
+		"lr",
+		"ls",
+		"lt",
+		"lu",
+		"lv",
+		"lw",
+		"lx",
+		"ly",
+		"lz",
+		"ma",
+		"mb",
+		"mc",
+		"md",
+		"me",
+		"mf",
+		"mg",
+		"mh",
+		"mi",
+		"mj",
+		"mk",
+		"ml",
+		"mm",
+		"mn",
+		"mo",
+		"mp",
+		"mq",
+		"mr",
+		"ms",
+		"mt",
+		"mu",
+		"mv",
+		"mw",
+		"mx",
+		"my",
+		"mz",
+		"na",
+		"nb",
+		"nc",
+		"nd",
+		"ne",
+		"nf",
+		"ng",
+		"nh",
+		"ni",
+		"nj",
+		"nk",
+		"nl",
+		"nm",
+		"nn",
+		"no",
+		"np",
+		"nq",
+		"nr",
+		"ns",
+		"nt",
+		"nu",
+		"nv",
+		"nw",
+		"nx",
+		"ny",
+		"nz",
+		"oa",
+		"ob",
+		"oc",
+		"od",
+		"oe",
+		"of",
+		"og",
+		"oh",
+		"oi",
+		"oj",
+		"ok",
+		"ol",
+		"om",
+		"on",
+		"oo",
+		"op",
+		"oq",
+		"or",
+		"os",
+		"ot",
+		"ou",
+		"ov",
+		"ow",
+		"ox",
+		"oy",
+		"oz",
+		"pa",
+		"pb",
+		"pc",
+		"pd",
+		"pe",
+		"pf",
+		"pg",
+		"ph",
+		"pi",
+		"pj",
+		"pk",
+		"pl",
+		"pm",
+		"pn",
+		"po",
+		"pp",
+		"pq",
+		"pr",
+		"ps",
+		"pt",
+		"pu",
+		"pv",
+		"pw",
+		"px",
+		"py",
+		"pz",
+		"qa",
+		"qb",
+		"qc",
+		"qd",
+		"qe",
+		"qf",
+		"qg",
+		"qh",
+		"qi",
+		"qj",
+		"qk",
+		"ql",
+		"qm",
+		"qn",
+		"qo",
+		"qp",
+		"qq",
+		"qr",
+		"qs",
+		"qt",
+		"qu",
+		"qv",
+		"qw",
+		"qx",
+		"qy",
+		"qz",
+		"ra",
+		"rb",
+		"rc",
+		"rd",
+		"re",
+		"rf",
+		"rg",
+		"rh",
+		"ri",
+		"rj",
+		"rk",
+		"rl",
+		"rm",
+		"rn",
+		"ro",
+		"rp",
+		"rq",
+		"rr",
+		"rs",
+		"rt",
+		"ru",
+		"rv",
+		"rw",
+		"rx",
+		"ry",
+		"rz",
+		"sa",
+		"sb",
+		"sc",
+		"sd",
+		"se",
+		"sf",
+		"sg",
+		"sh",
+		"si",
+		"sj",
+		"sk",
+		"sl",
+		"sm",
+		"sn",
+		"so",
+		"sp",
+		"sq",
+		"sr",
+		"ss",
+		"st",
+		"su",
+		"sv",
+		"sw",
+		"sx",
+		"sy",
+		"sz",
+		"ta",
+		"tb",
+		"tc",
+		"td",
+		"te",
+		"tf",
+		"tg",
+		"th",
+		"ti",
+		"tj",
+		"tk",
+		"tl",
+		"tm",
+		"tn",
+		"to",
+		"tp",
+		"tq",
+		"tr",
+		"ts",
+		"tt",
+		"tu",
+		"tv",
+		"tw",
+		"tx",
+		"ty",
+		"tz",
+		"ua",
+		"ub",
+		"uc",
+		"ud",
+		"ue",
+		"uf",
+		"ug",
+		"uh",
+		"ui",
+		"uj",
+		"uk",
+		"ul",
+		"um",
+		"un",
+		"uo",
+		"up",
+		"uq",
+		"ur",
+		"us",
+		"ut",
+		"uu",
+		"uv",
+		"uw",
+		"ux",
+		"uy",
+		"uz",
+		"va",
+		"vb",
+		"vc",
+		"vd",
+		"ve",
+		"vf",
+		"vg",
+		"vh",
+		"vi",
+		"vj",
+		"vk",
+		"vl",
+		"vm",
+		"vn",
+		"vo",
+		"vp",
+		"vq",
+		"vr",
+		"vs",
+		"vt",
+		"vu",
+		"vv",
+		"vw",
+		"vx",
+		"vy",
+		"vz",
+		"wa",
+		"wb",
+		"wc",
+		"wd",
+		"we",
+		"wf",
+		"wg",
+		"wh",
+		"wi",
+		"wj",
+		"wk",
+		"wl",
+		"wm",
+		"wn",
+		"wo",
+		"wp",
+		"wq",
+		"wr",
+		"ws",
+		"wt",
+		"wu",
+		"wv",
+		"ww",
+		"wx",
+		"wy",
+		"wz",
+		"xa",
+		"xb",
+		"xc",
+		"xd",
+		"xe",
+		"xf",
+		"xg",
+		"xh",
+		"xi",
+		"xj",
+		"xk",
+		"xl",
+		"xm",
+		"xn",
+		"xo",
+		"xp",
+		"xq",
+		"xr",
+		"xs",
+		"xt",
+		"xu",
+		"xv",
+		"xw",
+		"xx",
+		"xy",
+		"xz",
+		"ya",
+		"yb",
+		"yc",
+		"yd",
+		"ye",
+		"yf",
+		"yg",
+		"yh",
+		"yi",
+		"yj",
+		"yk",
+		"yl",
+		"ym",
+		"yn",
+		"yo",
+		"yp",
+		"yq",
+		"yr",
+		"ys",
+		"yt",
+		"yu",
+		"yv",
+		"yw",
+		"yx",
+		"yy",
+		"yz",
+		"za",
+		"zb",
+		"zc",
+		"zd",
+		"ze",
+		"zf",
+		"zg",
+		"zh",
+		"zi",
+		"zj",
+		"zk",
+		"zl",
+		"zm",
+		"zn",
+		"zo",
+		"zp",
+		"zq",
+		"zr",
+		"zs",
+		"zt",
+		"zu",
+		"zv",
+		"zw",
+		"zx",
+		"zy",
+		"zz",
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestSuffixesLotOfStrs(t *testing.T) {
+	suffixes, _ := NewSuffixes(SuffixAlphabetic, 4, 0, false)
+
+	last, ok := suffixes.Suffix(456975)
+	if !ok || last != "zzzz" {
+		t.Errorf("expected %v, got %v", "zzzz", last)
+	}
+	if _, ok := suffixes.Suffix(456976); ok {
+		t.Errorf("expected the suffixes to run out after %v", 456976)
+	}
+}
+
+func TestSuffixesZeroLength(t *testing.T) {
+	_, err := NewSuffixes(SuffixAlphabetic, 0, 0, false)
+	expected := fmt.Errorf("Error: suffix length must be greater than 0")
+	if err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+
+}
+
+func TestSuffixesLongLength(t *testing.T) {
+	suffixes, err := NewSuffixes(SuffixAlphabetic, 20, 0, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := suffixList(suffixes, 2)
+	expected := []string{"aaaaaaaaaaaaaaaaaaaa", "aaaaaaaaaaaaaaaaaaab"}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestSuffixesWiden(t *testing.T) {
+	tests := []struct {
+		kind     SuffixKind
+		index    int
+		expected string
+	}{
+		{kind: SuffixAlphabetic, index: 0, expected: "aa"},
+		{kind: SuffixAlphabetic, index: 649, expected: "yz"},
+		{kind: SuffixAlphabetic, index: 650, expected: "zaaa"},
+		{kind: SuffixAlphabetic, index: 650 + 25*26*26 - 1, expected: "zyzz"},
+		{kind: SuffixAlphabetic, index: 650 + 25*26*26, expected: "zzaaaa"},
+		{kind: SuffixAlphabetic, index: 20000000, expected: "zzzzartxvu"},
+		{kind: SuffixNumeric, index: 89, expected: "89"},
+		{kind: SuffixNumeric, index: 90, expected: "9000"},
+		{kind: SuffixNumeric, index: 990, expected: "990000"},
+	}
+
+	for _, tt := range tests {
+		suffixes, _ := NewSuffixes(tt.kind, 2, 0, true)
+		res, ok := suffixes.Suffix(tt.index)
+		if !ok || res != tt.expected {
+			t.Errorf("suffix %d: expected %v, got %v", tt.index, tt.expected, res)
+		}
+	}
+}
+
+func TestSuffixesNumeric(t *testing.T) {
+	tests := []struct {
+		kind     SuffixKind
+		length   int
+		start    int
+		expected []string
+	}{
+		{kind: SuffixNumeric, length: 1, start: 0, expected: []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}},
+		{kind: SuffixNumeric, length: 2, start: 97, expected: []string{"97", "98", "99"}},
+		{kind: SuffixHex, length: 2, start: 250, expected: []string{"fa", "fb", "fc", "fd", "fe", "ff"}},
+	}
+
+	for _, tt := range tests {
+		suffixes, err := NewSuffixes(tt.kind, tt.length, tt.start, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		res := suffixList(suffixes, 100)
+		if !reflect.DeepEqual(res, tt.expected) {
+			t.Errorf("expected %v, got %v", tt.expected, res)
+		}
+	}
+}
+
+func TestSuffixesStartTooLarge(t *testing.T) {
+	_, err := NewSuffixes(SuffixNumeric, 2, 100, false)
+	expected := fmt.Errorf("error: 100: suffix start value is too large for the suffix length")
+	if err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestSuffixesInvalid(t *testing.T) {
+	tests := []struct {
+		kind     SuffixKind
+		start    int
+		widen    bool
+		expected string
+	}{
+		{kind: SuffixKind(9), expected: "error: 9: unknown suffix kind"},
+		{kind: SuffixKind(9), widen: true, expected: "error: 9: unknown suffix kind"},
+		{kind: SuffixNumeric, start: -1, expected: "error: -1: suffix start value must not be negative"},
+		{kind: SuffixNumeric, start: -1, widen: true, expected: "error: -1: suffix start value must not be negative"},
+	}
+
+	for _, test := range tests {
+		_, err := NewSuffixes(test.kind, 2, test.start, test.widen)
+		if !errors.Is(err, ErrInvalidSuffix) || err.Error() != test.expected {
+			t.Errorf("expected %v, got %v", test.expected, err)
+		}
+	}
+
+	_, err := Split(context.Background(), strings.NewReader("1\n"), Options{Lines: 1, Output: Output{SuffixKind: 9}})
+	if !errors.Is(err, ErrInvalidSuffix) {
+		t.Errorf("expected %v, got %v", ErrInvalidSuffix, err)
+	}
+}
@@ -0,0 +1,194 @@
+package splitter
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Fields of a name template that only some split modes can fill in.
+const (
+	FieldTotal     = "total"
+	FieldOffset    = "offset"
+	FieldStartLine = "start_line"
+	FieldEndLine   = "end_line"
+)
+
+// templateFields are the fields a name template may use, and whether they are numbers.
+var templateFields = map[string]bool{
+	"prefix":       false,
+	"suffix":       false,
+	"ext":          false,
+	"index":        true,
+	"number":       true,
+	FieldTotal:     true,
+	FieldOffset:    true,
+	FieldStartLine: true,
+	FieldEndLine:   true,
+}
+
+// NameTemplate is a template for the names of the split files, such as "{prefix}{index:04}-of-{total}{ext}".
+//
+// Fields are written in braces: {prefix}, {suffix}, {ext} (the additional suffix), {index} (counting from 0),
+// {number} (counting from 1), {total}, {offset}, {start_line} and {end_line}. A number field can take a width,
+// as in {index:4}, which is padded with zeros when it starts with 0, as in {index:04}.
+// "{{" and "}}" stand for literal braces.
+type NameTemplate struct {
+	parts []templatePart
+}
+
+// templatePart is either a literal piece of a name template or a field.
+type templatePart struct {
+	literal string
+	field   string
+	width   int
+	zeroPad bool
+}
+
+// ParseNameTemplate is a function that parses and validates a name template.
+// The template has to contain {suffix}, {index} or {number}, so that every split file gets its own name,
+// and its literal text must not contain a slash, so that no split file is written outside Output.Dir.
+func ParseNameTemplate(s string) (*NameTemplate, error) {
+	t := &NameTemplate{}
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			t.parts = append(t.parts, templatePart{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"):
+			literal.WriteByte('{')
+			i++
+		case strings.HasPrefix(s[i:], "}}"):
+			literal.WriteByte('}')
+			i++
+		case s[i] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return nil, errorOf(ErrInvalidTemplate, "error: %s: unterminated field in name template", s)
+			}
+			part, err := parseTemplateField(s[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			flush()
+			t.parts = append(t.parts, part)
+			i += end
+		case s[i] == '}':
+			return nil, errorOf(ErrInvalidTemplate, "error: %s: unmatched } in name template", s)
+		default:
+			literal.WriteByte(s[i])
+		}
+	}
+	flush()
+
+	for _, part := range t.parts {
+		if hasPathSeparator(part.literal) {
+			return nil, errorOf(ErrInvalidTemplate, "error: %s: name template must not contain a slash", s)
+		}
+	}
+	if !t.uses("suffix") && !t.uses("index") && !t.uses("number") {
+		return nil, errorOf(ErrInvalidTemplate, "error: %s: name template must contain {suffix}, {index} or {number}", s)
+	}
+	return t, nil
+}
+
+// hasPathSeparator is a function that reports whether s contains a slash or the path separator of the system.
+func hasPathSeparator(s string) bool {
+	return strings.ContainsRune(s, '/') || strings.ContainsRune(s, os.PathSeparator)
+}
+
+// parseTemplateField is a function that parses the inside of a pair of braces, such as "index:04".
+func parseTemplateField(s string) (templatePart, error) {
+	name, spec, hasSpec := strings.Cut(s, ":")
+	isNumber, ok := templateFields[name]
+	if !ok {
+		return templatePart{}, errorOf(ErrInvalidTemplate, "error: {%s}: unknown field in name template", s)
+	}
+
+	part := templatePart{field: name}
+	if !hasSpec {
+		return part, nil
+	}
+	width, err := strconv.Atoi(spec)
+	if !isNumber || err != nil || width < 0 || strings.HasPrefix(spec, "+") || strings.HasPrefix(spec, "-") {
+		return templatePart{}, errorOf(ErrInvalidTemplate, "error: {%s}: illegal width in name template", s)
+	}
+	part.width = width
+	part.zeroPad = strings.HasPrefix(spec, "0")
+	return part, nil
+}
+
+// uses is a method that reports whether the template contains the field.
+func (t *NameTemplate) uses(field string) bool {
+	for _, part := range t.parts {
+		if part.field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Check is a method that returns an error if the template uses one of FieldTotal, FieldOffset,
+// FieldStartLine or FieldEndLine that is not among the provided fields.
+func (t *NameTemplate) Check(provided ...string) error {
+	for _, field := range []string{FieldTotal, FieldOffset, FieldStartLine, FieldEndLine} {
+		if !t.uses(field) {
+			continue
+		}
+		found := false
+		for _, p := range provided {
+			found = found || p == field
+		}
+		if !found {
+			return errorOf(ErrInvalidTemplate, "error: {%s} is not available in the name template when splitting this way", field)
+		}
+	}
+	return nil
+}
+
+// Expand is a method that returns the name of the part.
+func (t *NameTemplate) Expand(prefix string, ext string, part PartInfo) string {
+	var sb strings.Builder
+	for _, p := range t.parts {
+		var number int64
+		switch p.field {
+		case "":
+			sb.WriteString(p.literal)
+			continue
+		case "prefix":
+			sb.WriteString(prefix)
+			continue
+		case "suffix":
+			sb.WriteString(part.Suffix)
+			continue
+		case "ext":
+			sb.WriteString(ext)
+			continue
+		case "index":
+			number = int64(part.Index)
+		case "number":
+			number = int64(part.Index) + 1
+		case FieldTotal:
+			number = int64(part.Total)
+		case FieldOffset:
+			number = part.Offset
+		case FieldStartLine:
+			number = part.StartLine
+		case FieldEndLine:
+			number = part.EndLine
+		}
+
+		if p.zeroPad {
+			fmt.Fprintf(&sb, "%0*d", p.width, number)
+		} else {
+			fmt.Fprintf(&sb, "%*d", p.width, number)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+package splitter
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNameTemplateExpand(t *testing.T) {
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{template: "{prefix}{suffix}{ext}", expected: "events_ab.jsonl"},
+		{template: "{prefix}{index:04}{ext}", expected: "events_0007.jsonl"},
+		{template: "dump-part-{number:02}-of-{total}.sql", expected: "dump-part-08-of-12.sql"},
+		{template: "{prefix}{index}_{start_line}-{end_line}@{offset:3}", expected: "events_7_15-21@ 42"},
+		{template: "{{{index}}}", expected: "{7}"},
+	}
+
+	part := PartInfo{Index: 7, Suffix: "ab", Total: 12, Offset: 42, StartLine: 15, EndLine: 21}
+	for _, tt := range tests {
+		tmpl, err := ParseNameTemplate(tt.template)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.template, err)
+		}
+
+		res := tmpl.Expand("events_", ".jsonl", part)
+		if res != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.template, tt.expected, res)
+		}
+	}
+}
+
+func TestParseNameTemplateErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		expected error
+	}{
+		{template: "{prefix", expected: fmt.Errorf("error: {prefix: unterminated field in name template")},
+		{template: "{index}}x", expected: fmt.Errorf("error: {index}}x: unmatched } in name template")},
+		{template: "{name}{index}", expected: fmt.Errorf("error: {name}: unknown field in name template")},
+		{template: "{prefix:04}{index}", expected: fmt.Errorf("error: {prefix:04}: illegal width in name template")},
+		{template: "{index:-4}", expected: fmt.Errorf("error: {index:-4}: illegal width in name template")},
+		{template: "../{index}", expected: fmt.Errorf("error: ../{index}: name template must not contain a slash")},
+		{template: "{prefix}/{{x}}{suffix}", expected: fmt.Errorf("error: {prefix}/{{x}}{suffix}: name template must not contain a slash")},
+		{template: "{prefix}-of-{total}", expected: fmt.Errorf("error: {prefix}-of-{total}: name template must contain {suffix}, {index} or {number}")},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseNameTemplate(tt.template)
+		if err == nil || err.Error() != tt.expected.Error() {
+			t.Errorf("%s: expected %v, got %v", tt.template, tt.expected, err)
+		}
+	}
+}
+
+func TestNameTemplateCheck(t *testing.T) {
+	tmpl, _ := ParseNameTemplate("{index}-of-{total}")
+
+	if err := tmpl.Check(FieldTotal, FieldOffset); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+
+	err := tmpl.Check(FieldOffset, FieldStartLine, FieldEndLine)
+	expected := fmt.Errorf("error: {total} is not available in the name template when splitting this way")
+	if err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
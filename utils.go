@@ -2,17 +2,28 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"math"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"split/splitter"
 )
 
 // NormalizeArgs is a function that normalizes the arguments passed to the program.
 // For example, if the user passes "-l10" instead of "-l 10", this function will
 // normalize the arguments to "-l 10".
+// Long options such as "--prompt" are left untouched.
 func NormalizeArgs(args []string) []string {
 	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "--") {
+			continue
+		}
 		if strings.HasPrefix(args[i], "-l") && len(args[i]) > 2 {
 			args = append(args[:i], append([]string{"-l", args[i][2:]}, args[i+1:]...)...)
 		}
@@ -25,43 +36,65 @@ func NormalizeArgs(args []string) []string {
 		if strings.HasPrefix(args[i], "-a") && len(args[i]) > 2 {
 			args = append(args[:i], append([]string{"-a", args[i][2:]}, args[i+1:]...)...)
 		}
+		if strings.HasPrefix(args[i], "-p") && len(args[i]) > 2 {
+			args = append(args[:i], append([]string{"-p", args[i][2:]}, args[i+1:]...)...)
+		}
+		if strings.HasPrefix(args[i], "-C") && len(args[i]) > 2 {
+			args = append(args[:i], append([]string{"-C", args[i][2:]}, args[i+1:]...)...)
+		}
+		if strings.HasPrefix(args[i], "-o") && len(args[i]) > 2 {
+			args = append(args[:i], append([]string{"-o", args[i][2:]}, args[i+1:]...)...)
+		}
 	}
 	return args
 }
 
-// GenerateStrings is a function that generates strings from the given length.
-func GenerateStrings(length int, prefix string, counter int) ([]string, error) {
-	alphabet := "abcdefghijklmnopqrstuvwxyz"
+// suffixFlag is a flag.Value for options such as -d and --numeric-suffixes[=FROM]
+// that switch the suffixes to kind and optionally give the number of the first suffix.
+type suffixFlag struct {
+	kind  splitter.SuffixKind
+	dest  *splitter.SuffixKind
+	start *int
+}
 
-	if counter == 0 && length == 0 {
-		return []string{}, fmt.Errorf("Error: suffix length must be greater than 0")
-	}
-	if length == 0 {
-		return []string{prefix}, nil
-	}
-	if length > 5 {
-		return []string{}, fmt.Errorf("Error: suffix length must be less than or equal to 5")
+// IsBoolFlag lets the option be given without a value.
+func (f *suffixFlag) IsBoolFlag() bool {
+	return true
+}
+
+// String returns the number of the first suffix.
+func (f *suffixFlag) String() string {
+	if f == nil || f.start == nil {
+		return ""
 	}
+	return strconv.Itoa(*f.start)
+}
 
-	var result []string
-	for _, char := range alphabet {
-		counter++
-		res, err := GenerateStrings(length-1, prefix+string(char), counter)
-		if err != nil {
-			return []string{}, err
+// Set switches the suffix kind and parses the optional number of the first suffix.
+func (f *suffixFlag) Set(s string) error {
+	start := 0
+	if s != "true" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return fmt.Errorf("error: %s: illegal suffix start value", s)
 		}
-		result = append(result, res...)
+		start = n
 	}
-
-	return result, nil
+	*f.dest, *f.start = f.kind, start
+	return nil
 }
 
 // Args is a struct that represents the arguments passed to the program.
 type Args struct {
 	LineCount int
 	FileCount int
-	ByteSize  int
-	Args      []string
+	ByteSize  int64
+	LineBytes int64
+	Pattern   string
+	// ChunkIndex is the K of -n K/N, which writes only that chunk to standard output.
+	ChunkIndex int
+	Report     bool
+	Args       []string
 }
 
 // IllegalArgsChecker is a function that checks if the arguments passed to the program are valid.
@@ -69,19 +102,32 @@ func IllegalArgsChecker(params Args) error {
 	lineSetCount := 0
 	fileSetCount := 0
 	byteSetCount := 0
+	patternSetCount := 0
+	lineBytesSetCount := 0
+	forceSetCount := 0
+	noClobberSetCount := 0
 
-	lineCount, fileCount, byteSize, args := params.LineCount, params.FileCount, params.ByteSize, params.Args
+	lineCount, fileCount, byteSize, pattern, args := params.LineCount, params.FileCount, params.ByteSize, params.Pattern, params.Args
 
 	for _, arg := range args {
-		switch arg {
+		name, _, _ := strings.Cut(arg, "=")
+		switch name {
 		case "-l":
 			lineSetCount++
 		case "-n":
 			fileSetCount++
 		case "-b":
 			byteSetCount++
-		case "-a":
+		case "-p":
+			patternSetCount++
+		case "-C", "--line-bytes":
+			lineBytesSetCount++
+		case "-a", "-d", "--numeric-suffixes", "-x", "--hex-suffixes", "--additional-suffix", "--name-template", "-o", "--output-dir", "--mkdir-mode", "--fsync", "--keep-partial", "-e", "--elide-empty-files", "--filter", "--verbose", "--report", "--timeout", "--prompt", "-":
 			continue
+		case "--force":
+			forceSetCount++
+		case "--no-clobber":
+			noClobberSetCount++
 		default:
 			if strings.HasPrefix(arg, "-") {
 				return fmt.Errorf("Error: unknown option %s", arg)
@@ -89,15 +135,24 @@ func IllegalArgsChecker(params Args) error {
 		}
 	}
 
-	if lineSetCount+fileSetCount+byteSetCount > 1 {
+	if lineSetCount+fileSetCount+byteSetCount+patternSetCount+lineBytesSetCount > 1 {
 		return fmt.Errorf(
 			`usage: split [-l line_count] [-a suffix_length] [file [prefix]]
 			split -b byte_count[K|k|M|m|G|g] [-a suffix_length] [file [prefix]]
-			split -n chunk_count [-a suffix_length] [file [prefix]]
+			split -C line_bytes[K|k|M|m|G|g] [-a suffix_length] [file [prefix]]
+			split -n chunk_count|l/chunk_count|r/chunk_count|K/chunk_count|l/K/chunk_count|r/K/chunk_count [-a suffix_length] [file [prefix]]
 			split -p pattern [-a suffix_length] [file [prefix]]`,
 		)
 	}
 
+	if params.ChunkIndex > 0 && params.Report {
+		return fmt.Errorf("error: --report can't be used with -n K/N, which writes no split files")
+	}
+
+	if forceSetCount > 0 && noClobberSetCount > 0 {
+		return fmt.Errorf("error: --force and --no-clobber can't be used together")
+	}
+
 	if lineCount <= 0 && lineSetCount == 1 {
 		return fmt.Errorf("error: %d: illegal line count", lineCount)
 	}
@@ -109,30 +164,279 @@ func IllegalArgsChecker(params Args) error {
 	if byteSize <= 0 && byteSetCount == 1 {
 		return fmt.Errorf("error: %d: illegal byte size", byteSize)
 	}
+
+	if params.LineBytes <= 0 && lineBytesSetCount == 1 {
+		return fmt.Errorf("error: %d: illegal line byte count", params.LineBytes)
+	}
+
+	if patternSetCount == 1 {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("error: %s: illegal regexp", pattern)
+		}
+	}
+	return nil
+}
+
+// sizeUnits maps the unit suffixes accepted by ParseSize to their multipliers.
+// Single letters follow split(1) and mean powers of 1024, "KB" style units are
+// powers of 1000 and "KiB" style units are powers of 1024.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"K":   1 << 10,
+	"k":   1 << 10,
+	"KiB": 1 << 10,
+	"KB":  1e3,
+	"kB":  1e3,
+	"M":   1 << 20,
+	"m":   1 << 20,
+	"MiB": 1 << 20,
+	"MB":  1e6,
+	"G":   1 << 30,
+	"g":   1 << 30,
+	"GiB": 1 << 30,
+	"GB":  1e9,
+	"T":   1 << 40,
+	"t":   1 << 40,
+	"TiB": 1 << 40,
+	"TB":  1e12,
+	"P":   1 << 50,
+	"p":   1 << 50,
+	"PiB": 1 << 50,
+	"PB":  1e15,
+	"E":   1 << 60,
+	"e":   1 << 60,
+	"EiB": 1 << 60,
+	"EB":  1e18,
+}
+
+// ParseSize is a function that parses a size such as "100", "10M", "10MB" or "1GiB" into a number of bytes.
+// It is used by every option that takes a size.
+func ParseSize(s string) (int64, error) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("error: %s: illegal size", s)
+	}
+
+	multiplier, ok := sizeUnits[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("error: %s: unknown size unit %q", s, s[i:])
+	}
+
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil || n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("error: %s: size is too large", s)
+	}
+	return n * multiplier, nil
+}
+
+// ByteSize is a flag.Value that holds a size parsed by ParseSize.
+type ByteSize int64
+
+// String returns the size in bytes.
+func (b *ByteSize) String() string {
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+// Set parses the size given on the command line.
+func (b *ByteSize) Set(s string) error {
+	n, err := ParseSize(s)
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+// ReportFormat is a flag.Value for --report. The only format is "json", which can be followed by ":PATH"
+// to write the report to a file instead of standard error.
+type ReportFormat string
+
+// String is a method that returns the format.
+func (f *ReportFormat) String() string {
+	return string(*f)
+}
+
+// Set is a method that checks the format is one split can write.
+func (f *ReportFormat) Set(s string) error {
+	format, path, hasPath := strings.Cut(s, ":")
+	if format != "json" {
+		return fmt.Errorf("error: %s: unknown report format", s)
+	}
+	if hasPath && path == "" {
+		return fmt.Errorf("error: %s: missing report path", s)
+	}
+	*f = ReportFormat(s)
+	return nil
+}
+
+// Path is a method that returns the file to write the report to, or "" for standard error.
+func (f ReportFormat) Path() string {
+	_, path, _ := strings.Cut(string(f), ":")
+	return path
+}
+
+// ChunkSpec is a flag.Value that holds an argument of the -n option such as "4", "l/4", "r/4", "2/4" or "l/2/4".
+// Index is the single chunk (counting from 1) to write to standard output, or 0 to write every chunk to its own file.
+type ChunkSpec struct {
+	Mode  splitter.ChunkMode
+	Index int
+	Count int
+}
+
+// String returns the argument in the form it was given.
+func (c *ChunkSpec) String() string {
+	s := strconv.Itoa(c.Count)
+	if c.Index > 0 {
+		s = fmt.Sprintf("%d/%s", c.Index, s)
+	}
+	switch c.Mode {
+	case splitter.ChunkLines:
+		s = "l/" + s
+	case splitter.ChunkRoundRobin:
+		s = "r/" + s
+	}
+	return s
+}
+
+// Set parses the argument given on the command line.
+func (c *ChunkSpec) Set(s string) error {
+	mode := splitter.ChunkBytes
+	rest := s
+	if after, ok := strings.CutPrefix(rest, "l/"); ok {
+		mode = splitter.ChunkLines
+		rest = after
+	} else if after, ok := strings.CutPrefix(rest, "r/"); ok {
+		mode = splitter.ChunkRoundRobin
+		rest = after
+	}
+
+	index := 0
+	before, after, hasIndex := strings.Cut(rest, "/")
+	if hasIndex {
+		var err error
+		index, err = strconv.Atoi(before)
+		if err != nil {
+			return fmt.Errorf("error: %s: illegal chunk index", s)
+		}
+		rest = after
+	}
+
+	count, err := strconv.Atoi(rest)
+	if err != nil {
+		return fmt.Errorf("error: %s: illegal file count", s)
+	}
+	if hasIndex && (index < 1 || index > count) {
+		return fmt.Errorf("error: %s: chunk index must be between 1 and %d", s, count)
+	}
+	c.Mode, c.Index, c.Count = mode, index, count
+	return nil
+}
+
+// FileMode is a flag.Value that holds permissions given in octal, such as "0750".
+type FileMode os.FileMode
+
+// String returns the permissions in octal.
+func (m *FileMode) String() string {
+	return fmt.Sprintf("%#o", uint32(*m))
+}
+
+// Set parses the octal permissions given on the command line.
+func (m *FileMode) Set(s string) error {
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 0o7777 {
+		return fmt.Errorf("error: %s: illegal mode", s)
+	}
+	*m = FileMode(n)
 	return nil
 }
 
 // ParseArgsResult is a struct that represents the result of parsing the arguments passed to the program.
 type ParseArgsResult struct {
-	LineCount int
-	FileCount int
-	ByteSize  int
-	SuffixLen int
-	Args      []string
+	LineCount        int
+	FileCount        int
+	ChunkMode        splitter.ChunkMode
+	ChunkIndex       int
+	ByteSize         int64
+	LineBytes        int64
+	SuffixLen        int
+	SuffixLenSet     bool
+	SuffixKind       splitter.SuffixKind
+	SuffixStart      int
+	AdditionalSuffix string
+	NameTemplate     string
+	OutputDir        string
+	MkdirMode        os.FileMode
+	Force            bool
+	Fsync            bool
+	KeepPartial      bool
+	ElideEmpty       bool
+	Filter           string
+	Verbose          bool
+	Report           ReportFormat
+	Timeout          time.Duration
+	Pattern          string
+	Prompt           bool
+	Args             []string
 }
 
 // ParseArgs is a function that parses the arguments passed to the program.
 // It does not care about semantics. Just parse the arguments.
 func ParseArgs(fs *flag.FlagSet) (ParseArgsResult, error) {
 	var lineCount int
-	var fileCount int
-	var byteSize int
+	var chunks ChunkSpec
+	var byteSize ByteSize
+	var lineBytes ByteSize
 	var suffixLen int
+	var suffixKind splitter.SuffixKind
+	var suffixStart int
+	var additionalSuffix string
+	var nameTemplate string
+	var outputDir string
+	var mkdirMode FileMode
+	var force bool
+	var noClobber bool
+	var fsync bool
+	var keepPartial bool
+	var elideEmpty bool
+	var filter string
+	var verbose bool
+	var report ReportFormat
+	var timeout time.Duration
+	var pattern string
+	var prompt bool
 
 	fs.IntVar(&lineCount, "l", 0, "Number of lines per split file.")
-	fs.IntVar(&fileCount, "n", 0, "Number of files to split into.")
-	fs.IntVar(&byteSize, "b", 0, "Number of bytes per split file.")
-	fs.IntVar(&suffixLen, "a", 2, "Suffix length.")
+	fs.Var(&chunks, "n", "Number of files to split into, as N, l/N to keep lines whole or r/N to deal lines out in turn. K/N, l/K/N or r/K/N writes only chunk K to standard output.")
+	fs.Var(&byteSize, "b", "Number of bytes per split file, optionally with a K, M, G, KB or KiB style unit.")
+	fs.Var(&lineBytes, "C", "Maximum number of bytes of whole lines per split file, with the same units as -b.")
+	fs.Var(&lineBytes, "line-bytes", "Same as -C.")
+	fs.IntVar(&suffixLen, "a", splitter.DefaultSuffixLen, "Suffix length.")
+	numeric := &suffixFlag{kind: splitter.SuffixNumeric, dest: &suffixKind, start: &suffixStart}
+	fs.Var(numeric, "d", "Use numeric suffixes starting at 0.")
+	fs.Var(numeric, "numeric-suffixes", "Use numeric suffixes, starting at FROM with --numeric-suffixes=FROM.")
+	hex := &suffixFlag{kind: splitter.SuffixHex, dest: &suffixKind, start: &suffixStart}
+	fs.Var(hex, "x", "Use hexadecimal suffixes starting at 0.")
+	fs.Var(hex, "hex-suffixes", "Use hexadecimal suffixes, starting at FROM with --hex-suffixes=FROM.")
+	fs.StringVar(&additionalSuffix, "additional-suffix", "", "Extra suffix, such as .txt, appended to the names of the split files.")
+	fs.StringVar(&nameTemplate, "name-template", "", "Template for the names of the split files, such as {prefix}{index:04}-of-{total}{ext}.")
+	fs.StringVar(&outputDir, "o", "", "Directory to write the split files to, created if it does not exist.")
+	fs.StringVar(&outputDir, "output-dir", "", "Same as -o.")
+	fs.Var(&mkdirMode, "mkdir-mode", "Octal permissions of the output directory when it is created, such as 0750.")
+	fs.BoolVar(&force, "force", false, "Overwrite split files that already exist.")
+	fs.BoolVar(&noClobber, "no-clobber", false, "Refuse to overwrite split files that already exist. This is the default.")
+	fs.BoolVar(&fsync, "fsync", false, "Flush every split file to disk before giving it its final name.")
+	fs.BoolVar(&keepPartial, "keep-partial", false, "Keep the split files already written when splitting fails or is interrupted.")
+	fs.BoolVar(&elideEmpty, "e", false, "Don't create the empty files that -n can leave when the input is small.")
+	fs.BoolVar(&elideEmpty, "elide-empty-files", false, "Same as -e.")
+	fs.StringVar(&filter, "filter", "", "Shell command to pipe every split file to instead of writing it, with $FILE set to its name.")
+	fs.BoolVar(&verbose, "verbose", false, "Print a line for every split file as it is created.")
+	fs.Var(&report, "report", "Print a summary of the split files to standard error when done. The only format is json; json:PATH writes it to PATH instead.")
+	fs.DurationVar(&timeout, "timeout", 0, "Give up splitting after this long, such as 30s or 5m, and remove the split files written so far.")
+	fs.BoolVar(&prompt, "prompt", false, "Ask for the file name when none is given instead of reading standard input.")
+	fs.StringVar(&pattern, "p", "", "Regular expression; every matching line starts a new split file.")
 
 	args := NormalizeArgs(os.Args[1:])
 
@@ -140,18 +444,83 @@ func ParseArgs(fs *flag.FlagSet) (ParseArgsResult, error) {
 	if err != nil {
 		return ParseArgsResult{}, fmt.Errorf("error: fail to parse arguments, %v", err)
 	}
+	if suffixLen <= 0 {
+		return ParseArgsResult{}, fmt.Errorf("Error: suffix length must be greater than 0")
+	}
+	if timeout < 0 {
+		return ParseArgsResult{}, fmt.Errorf("error: %v: illegal timeout", timeout)
+	}
+
+	suffixLenSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "a" {
+			suffixLenSet = true
+		}
+	})
 	return ParseArgsResult{
-		LineCount: lineCount,
-		FileCount: fileCount,
-		ByteSize:  byteSize,
-		SuffixLen: suffixLen,
-		Args:      args,
+		LineCount:        lineCount,
+		FileCount:        chunks.Count,
+		ChunkMode:        chunks.Mode,
+		ChunkIndex:       chunks.Index,
+		ByteSize:         int64(byteSize),
+		LineBytes:        int64(lineBytes),
+		SuffixLen:        suffixLen,
+		SuffixLenSet:     suffixLenSet,
+		SuffixKind:       suffixKind,
+		SuffixStart:      suffixStart,
+		AdditionalSuffix: additionalSuffix,
+		NameTemplate:     nameTemplate,
+		OutputDir:        outputDir,
+		MkdirMode:        os.FileMode(mkdirMode),
+		Force:            force,
+		Fsync:            fsync,
+		KeepPartial:      keepPartial,
+		ElideEmpty:       elideEmpty,
+		Filter:           filter,
+		Verbose:          verbose,
+		Report:           report,
+		Timeout:          timeout,
+		Pattern:          pattern,
+		Prompt:           prompt,
+		Args:             args,
 	}, nil
 }
 
+// WriteReport is a function that writes the summary of the split files as JSON to the path of the report format,
+// or to standard error when it has none, so that it never mixes with what split writes to standard output.
+func WriteReport(report ReportFormat, result splitter.Result) error {
+	path := report.Path()
+	if path == "" {
+		if err := json.NewEncoder(os.Stderr).Encode(result); err != nil {
+			return fmt.Errorf("error: writing the report: %v", err)
+		}
+		return nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error: writing the report: %v", err)
+	}
+	err = json.NewEncoder(file).Encode(result)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error: writing the report: %v", err)
+	}
+	return nil
+}
+
+// StdinFileName is the file name that stands for standard input.
+const StdinFileName = "-"
+
 // GetFileName is a function that gets the file name from the user.
-// If user does not provide the file name, it will ask the user to enter the file name.
-func GetFileName(nonFlagArgs []string, reader *bufio.Reader) (string, error) {
+// If user does not provide the file name, the input is read from standard input,
+// unless prompt is set, in which case it will ask the user to enter the file name.
+func GetFileName(nonFlagArgs []string, reader *bufio.Reader, prompt bool) (string, error) {
+	if len(nonFlagArgs) == 0 && !prompt {
+		return StdinFileName, nil
+	}
 	if len(nonFlagArgs) == 0 {
 		fmt.Println("File name not provided. Please enter the file name:")
 		var input string
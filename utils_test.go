@@ -5,9 +5,15 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"split/splitter"
 )
 
 func TestNormalizeArgsBasicCase(t *testing.T) {
@@ -28,717 +34,15 @@ func TestNormalizeArgsWithNoSpaceBetweenFlagAndArg(t *testing.T) {
 	}
 }
 
-func TestGenerateStrings(t *testing.T) {
-	res, _ := GenerateStrings(2, "", 0)
-	expected := []string{
-		"aa",
-		"ab",
-		"ac",
-		"ad",
-		"ae",
-		"af",
-		"ag",
-		"ah",
-		"ai",
-		"aj",
-		"ak",
-		"al",
-		"am",
-		"an",
-		"ao",
-		"ap",
-		"aq",
-		"ar",
-		"as",
-		"at",
-		"au",
-		"av",
-		"aw",
-		"ax",
-		"ay",
-		"az",
-		"ba",
-		"bb",
-		"bc",
-		"bd",
-		"be",
-		"bf",
-		"bg",
-		"bh",
-		"bi",
-		"bj",
-		"bk",
-		"bl",
-		"bm",
-		"bn",
-		"bo",
-		"bp",
-		"bq",
-		"br",
-		"bs",
-		"bt",
-		"bu",
-		"bv",
-		"bw",
-		"bx",
-		"by",
-		"bz",
-		"ca",
-		"cb",
-		"cc",
-		"cd",
-		"ce",
-		"cf",
-		"cg",
-		"ch",
-		"ci",
-		"cj",
-		"ck",
-		"cl",
-		"cm",
-		"cn",
-		"co",
-		"cp",
-		"cq",
-		"cr",
-		"cs",
-		"ct",
-		"cu",
-		"cv",
-		"cw",
-		"cx",
-		"cy",
-		"cz",
-		"da",
-		"db",
-		"dc",
-		"dd",
-		"de",
-		"df",
-		"dg",
-		"dh",
-		"di",
-		"dj",
-		"dk",
-		"dl",
-		"dm",
-		"dn",
-		"do",
-		"dp",
-		"dq",
-		"dr",
-		"ds",
-		"dt",
-		"du",
-		"dv",
-		"dw",
-		"dx",
-		"dy",
-		"dz",
-		"ea",
-		"eb",
-		"ec",
-		"ed",
-		"ee",
-		"ef",
-		"eg",
-		"eh",
-		"ei",
-		"ej",
-		"ek",
-		"el",
-		"em",
-		"en",
-		"eo",
-		"ep",
-		"eq",
-		"er",
-		"es",
-		"et",
-		"eu",
-		"ev",
-		"ew",
-		"ex",
-		"ey",
-		"ez",
-		"fa",
-		"fb",
-		"fc",
-		"fd",
-		"fe",
-		"ff",
-		"fg",
-		"fh",
-		"fi",
-		"fj",
-		"fk",
-		"fl",
-		"fm",
-		"fn",
-		"fo",
-		"fp",
-		"fq",
-		"fr",
-		"fs",
-		"ft",
-		"fu",
-		"fv",
-		"fw",
-		"fx",
-		"fy",
-		"fz",
-		"ga",
-		"gb",
-		"gc",
-		"gd",
-		"ge",
-		"gf",
-		"gg",
-		"gh",
-		"gi",
-		"gj",
-		"gk",
-		"gl",
-		"gm",
-		"gn",
-		"go",
-		"gp",
-		"gq",
-		"gr",
-		"gs",
-		"gt",
-		"gu",
-		"gv",
-		"gw",
-		"gx",
-		"gy",
-		"gz",
-		"ha",
-		"hb",
-		"hc",
-		"hd",
-		"he",
-		"hf",
-		"hg",
-		"hh",
-		"hi",
-		"hj",
-		"hk",
-		"hl",
-		"hm",
-		"hn",
-		"ho",
-		"hp",
-		"hq",
-		"hr",
-		"hs",
-		"ht",
-		"hu",
-		"hv",
-		"hw",
-		"hx",
-		"hy",
-		"hz",
-		"ia",
-		"ib",
-		"ic",
-		"id",
-		"ie",
-		"if",
-		"ig",
-		"ih",
-		"ii",
-		"ij",
-		"ik",
-		"il",
-		"im",
-		"in",
-		"io",
-		"ip",
-		"iq",
-		"ir",
-		"is",
-		"it",
-		"iu",
-		"iv",
-		"iw",
-		"ix",
-		"iy",
-		"iz",
-		"ja",
-		"jb",
-		"jc",
-		"jd",
-		"je",
-		"jf",
-		"jg",
-		"jh",
-		"ji",
-		"jj",
-		"jk",
-		"jl",
-		"jm",
-		"jn",
-		"jo",
-		"jp",
-		"jq",
-		"jr",
-		"js",
-		"jt",
-		"ju",
-		"jv",
-		"jw",
-		"jx",
-		"jy",
-		"jz",
-		"ka",
-		"kb",
-		"kc",
-		"kd",
-		"ke",
-		"kf",
-		"kg",
-		"kh",
-		"ki",
-		"kj",
-		"kk",
-		"kl",
-		"km",
-		"kn",
-		"ko",
-		"kp",
-		"kq",
-		"kr",
-		"ks",
-		"kt",
-		"ku",
-		"kv",
-		"kw",
-		"kx",
-		"ky",
-		"kz",
-		"la",
-		"lb",
-		"lc",
-		"ld",
-		"le",
-		"lf",
-		"lg",
-		"lh",
-		"li",
-		"lj",
-		"lk",
-		"ll",
-		"lm",
-		"ln",
-		"lo",
-		"lp",
-		"lq",
-		"lr",
-		"ls",
-		"lt",
-		"lu",
-		"lv",
-		"lw",
-		"lx",
-		"ly",
-		"lz",
-		"ma",
-		"mb",
-		"mc",
-		"md",
-		"me",
-		"mf",
-		"mg",
-		"mh",
-		"mi",
-		"mj",
-		"mk",
-		"ml",
-		"mm",
-		"mn",
-		"mo",
-		"mp",
-		"mq",
-		"mr",
-		"ms",
-		"mt",
-		"mu",
-		"mv",
-		"mw",
-		"mx",
-		"my",
-		"mz",
-		"na",
-		"nb",
-		"nc",
-		"nd",
-		"ne",
-		"nf",
-		"ng",
-		"nh",
-		"ni",
-		"nj",
-		"nk",
-		"nl",
-		"nm",
-		"nn",
-		"no",
-		"np",
-		"nq",
-		"nr",
-		"ns",
-		"nt",
-		"nu",
-		"nv",
-		"nw",
-		"nx",
-		"ny",
-		"nz",
-		"oa",
-		"ob",
-		"oc",
-		"od",
-		"oe",
-		"of",
-		"og",
-		"oh",
-		"oi",
-		"oj",
-		"ok",
-		"ol",
-		"om",
-		"on",
-		"oo",
-		"op",
-		"oq",
-		"or",
-		"os",
-		"ot",
-		"ou",
-		"ov",
-		"ow",
-		"ox",
-		"oy",
-		"oz",
-		"pa",
-		"pb",
-		"pc",
-		"pd",
-		"pe",
-		"pf",
-		"pg",
-		"ph",
-		"pi",
-		"pj",
-		"pk",
-		"pl",
-		"pm",
-		"pn",
-		"po",
-		"pp",
-		"pq",
-		"pr",
-		"ps",
-		"pt",
-		"pu",
-		"pv",
-		"pw",
-		"px",
-		"py",
-		"pz",
-		"qa",
-		"qb",
-		"qc",
-		"qd",
-		"qe",
-		"qf",
-		"qg",
-		"qh",
-		"qi",
-		"qj",
-		"qk",
-		"ql",
-		"qm",
-		"qn",
-		"qo",
-		"qp",
-		"qq",
-		"qr",
-		"qs",
-		"qt",
-		"qu",
-		"qv",
-		"qw",
-		"qx",
-		"qy",
-		"qz",
-		"ra",
-		"rb",
-		"rc",
-		"rd",
-		"re",
-		"rf",
-		"rg",
-		"rh",
-		"ri",
-		"rj",
-		"rk",
-		"rl",
-		"rm",
-		"rn",
-		"ro",
-		"rp",
-		"rq",
-		"rr",
-		"rs",
-		"rt",
-		"ru",
-		"rv",
-		"rw",
-		"rx",
-		"ry",
-		"rz",
-		"sa",
-		"sb",
-		"sc",
-		"sd",
-		"se",
-		"sf",
-		"sg",
-		"sh",
-		"si",
-		"sj",
-		"sk",
-		"sl",
-		"sm",
-		"sn",
-		"so",
-		"sp",
-		"sq",
-		"sr",
-		"ss",
-		"st",
-		"su",
-		"sv",
-		"sw",
-		"sx",
-		"sy",
-		"sz",
-		"ta",
-		"tb",
-		"tc",
-		"td",
-		"te",
-		"tf",
-		"tg",
-		"th",
-		"ti",
-		"tj",
-		"tk",
-		"tl",
-		"tm",
-		"tn",
-		"to",
-		"tp",
-		"tq",
-		"tr",
-		"ts",
-		"tt",
-		"tu",
-		"tv",
-		"tw",
-		"tx",
-		"ty",
-		"tz",
-		"ua",
-		"ub",
-		"uc",
-		"ud",
-		"ue",
-		"uf",
-		"ug",
-		"uh",
-		"ui",
-		"uj",
-		"uk",
-		"ul",
-		"um",
-		"un",
-		"uo",
-		"up",
-		"uq",
-		"ur",
-		"us",
-		"ut",
-		"uu",
-		"uv",
-		"uw",
-		"ux",
-		"uy",
-		"uz",
-		"va",
-		"vb",
-		"vc",
-		"vd",
-		"ve",
-		"vf",
-		"vg",
-		"vh",
-		"vi",
-		"vj",
-		"vk",
-		"vl",
-		"vm",
-		"vn",
-		"vo",
-		"vp",
-		"vq",
-		"vr",
-		"vs",
-		"vt",
-		"vu",
-		"vv",
-		"vw",
-		"vx",
-		"vy",
-		"vz",
-		"wa",
-		"wb",
-		"wc",
-		"wd",
-		"we",
-		"wf",
-		"wg",
-		"wh",
-		"wi",
-		"wj",
-		"wk",
-		"wl",
-		"wm",
-		"wn",
-		"wo",
-		"wp",
-		"wq",
-		"wr",
-		"ws",
-		"wt",
-		"wu",
-		"wv",
-		"ww",
-		"wx",
-		"wy",
-		"wz",
-		"xa",
-		"xb",
-		"xc",
-		"xd",
-		"xe",
-		"xf",
-		"xg",
-		"xh",
-		"xi",
-		"xj",
-		"xk",
-		"xl",
-		"xm",
-		"xn",
-		"xo",
-		"xp",
-		"xq",
-		"xr",
-		"xs",
-		"xt",
-		"xu",
-		"xv",
-		"xw",
-		"xx",
-		"xy",
-		"xz",
-		"ya",
-		"yb",
-		"yc",
-		"yd",
-		"ye",
-		"yf",
-		"yg",
-		"yh",
-		"yi",
-		"yj",
-		"yk",
-		"yl",
-		"ym",
-		"yn",
-		"yo",
-		"yp",
-		"yq",
-		"yr",
-		"ys",
-		"yt",
-		"yu",
-		"yv",
-		"yw",
-		"yx",
-		"yy",
-		"yz",
-		"za",
-		"zb",
-		"zc",
-		"zd",
-		"ze",
-		"zf",
-		"zg",
-		"zh",
-		"zi",
-		"zj",
-		"zk",
-		"zl",
-		"zm",
-		"zn",
-		"zo",
-		"zp",
-		"zq",
-		"zr",
-		"zs",
-		"zt",
-		"zu",
-		"zv",
-		"zw",
-		"zx",
-		"zy",
-		"zz",
-	}
+func TestNormalizeArgsWithPattern(t *testing.T) {
+	res := NormalizeArgs([]string{"-p^foo", "test.txt"})
+	expected := []string{"-p", "^foo", "test.txt"}
+
 	if !reflect.DeepEqual(res, expected) {
 		t.Errorf("expected %v, got %v", expected, res)
 	}
 }
 
-func TestGenerateStringsLotOfStrs(t *testing.T) {
-	res, _ := GenerateStrings(4, "", 0)
-	resLen := len(res)
-	expectedLen := 456976
-	if resLen != expectedLen {
-		t.Errorf("expected %v, got %v", expectedLen, resLen)
-	}
-}
-
-func TestGenerateStringsZeroLength(t *testing.T) {
-	_, err := GenerateStrings(0, "", 0)
-	expected := fmt.Errorf("Error: suffix length must be greater than 0")
-	if err.Error() != expected.Error() {
-		t.Errorf("expected %v, got %v", expected, err)
-	}
-
-}
-
-func TestGenerateStringsTooBigLength(t *testing.T) {
-	_, err := GenerateStrings(6, "", 0)
-	expected := fmt.Errorf("Error: suffix length must be less than or equal to 5")
-	if err.Error() != expected.Error() {
-		t.Errorf("expected %v, got %v", expected, err)
-	}
-}
-
 func TestIllegalArgsChecker(t *testing.T) {
 	err := IllegalArgsChecker(Args{LineCount: 1, FileCount: 0, ByteSize: 0, Args: []string{"-l", "10", "-a", "3", "test.txt"}})
 	if err != nil {
@@ -751,7 +55,8 @@ func TestIllegalArgsCheckerDuplicateArgs(t *testing.T) {
 	expected := fmt.Errorf(
 		`usage: split [-l line_count] [-a suffix_length] [file [prefix]]
 			split -b byte_count[K|k|M|m|G|g] [-a suffix_length] [file [prefix]]
-			split -n chunk_count [-a suffix_length] [file [prefix]]
+			split -C line_bytes[K|k|M|m|G|g] [-a suffix_length] [file [prefix]]
+			split -n chunk_count|l/chunk_count|r/chunk_count|K/chunk_count|l/K/chunk_count|r/K/chunk_count [-a suffix_length] [file [prefix]]
 			split -p pattern [-a suffix_length] [file [prefix]]`)
 	if err.Error() != expected.Error() {
 		t.Errorf("expected %v, got %v", expected, err)
@@ -763,7 +68,8 @@ func TestIllegalArgsCheckerMultipleArgs(t *testing.T) {
 	expected := fmt.Errorf(
 		`usage: split [-l line_count] [-a suffix_length] [file [prefix]]
 			split -b byte_count[K|k|M|m|G|g] [-a suffix_length] [file [prefix]]
-			split -n chunk_count [-a suffix_length] [file [prefix]]
+			split -C line_bytes[K|k|M|m|G|g] [-a suffix_length] [file [prefix]]
+			split -n chunk_count|l/chunk_count|r/chunk_count|K/chunk_count|l/K/chunk_count|r/K/chunk_count [-a suffix_length] [file [prefix]]
 			split -p pattern [-a suffix_length] [file [prefix]]`,
 	)
 	if err.Error() != expected.Error() {
@@ -788,6 +94,41 @@ func TestIllegalArgsCheckerInvalidValue(t *testing.T) {
 	}
 }
 
+func TestIllegalArgsCheckerPatternWithLines(t *testing.T) {
+	err := IllegalArgsChecker(Args{LineCount: 3, Pattern: "foo", Args: []string{"-p", "foo", "-l", "3", "test.txt"}})
+	if err == nil || !strings.HasPrefix(err.Error(), "usage: split") {
+		t.Errorf("expected usage error, got %v", err)
+	}
+}
+
+func TestIllegalArgsCheckerLineBytes(t *testing.T) {
+	err := IllegalArgsChecker(Args{LineBytes: 1024, Args: []string{"--line-bytes=1K", "test.txt"}})
+	if err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+
+	err = IllegalArgsChecker(Args{LineCount: 3, LineBytes: 1024, Args: []string{"-C", "1K", "-l", "3", "test.txt"}})
+	if err == nil || !strings.HasPrefix(err.Error(), "usage: split") || !strings.Contains(err.Error(), "split -C line_bytes") {
+		t.Errorf("expected usage error mentioning -C, got %v", err)
+	}
+}
+
+func TestIllegalArgsCheckerForceAndNoClobber(t *testing.T) {
+	err := IllegalArgsChecker(Args{LineCount: 3, Args: []string{"-l", "3", "--force", "--no-clobber", "test.txt"}})
+	expected := fmt.Errorf("error: --force and --no-clobber can't be used together")
+	if err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestIllegalArgsCheckerIllegalPattern(t *testing.T) {
+	err := IllegalArgsChecker(Args{Pattern: "[a-", Args: []string{"-p", "[a-", "test.txt"}})
+	expected := fmt.Errorf("error: [a-: illegal regexp")
+	if err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
+
 func TestParseArgs(t *testing.T) {
 	oldArgs := os.Args
 	defer func() { os.Args = oldArgs }() // テスト後にos.Argsを元に戻す
@@ -797,11 +138,12 @@ func TestParseArgs(t *testing.T) {
 	res, _ := ParseArgs(fs)
 
 	expected := ParseArgsResult{
-		LineCount: 10,
-		FileCount: 0,
-		ByteSize:  0,
-		SuffixLen: 5,
-		Args:      []string{"-l", "10", "-a", "5"},
+		LineCount:    10,
+		FileCount:    0,
+		ByteSize:     0,
+		SuffixLen:    5,
+		SuffixLenSet: true,
+		Args:         []string{"-l", "10", "-a", "5"},
 	}
 
 	if !reflect.DeepEqual(res, expected) {
@@ -830,6 +172,170 @@ func TestParseArgsInvalidSemantics(t *testing.T) {
 	}
 }
 
+func TestParseArgsSuffixKind(t *testing.T) {
+	tests := []struct {
+		args          []string
+		expectedKind  splitter.SuffixKind
+		expectedStart int
+	}{
+		{args: []string{"./main", "-d", "-a", "3"}, expectedKind: splitter.SuffixNumeric, expectedStart: 0},
+		{args: []string{"./main", "--numeric-suffixes=7"}, expectedKind: splitter.SuffixNumeric, expectedStart: 7},
+		{args: []string{"./main", "-x"}, expectedKind: splitter.SuffixHex, expectedStart: 0},
+		{args: []string{"./main", "--hex-suffixes=16"}, expectedKind: splitter.SuffixHex, expectedStart: 16},
+		{args: []string{"./main", "-l", "2"}, expectedKind: splitter.SuffixAlphabetic, expectedStart: 0},
+	}
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	for _, tt := range tests {
+		os.Args = tt.args
+		fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+		res, err := ParseArgs(fs)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.args, err)
+		}
+
+		if res.SuffixKind != tt.expectedKind || res.SuffixStart != tt.expectedStart {
+			t.Errorf("%v: expected kind %v from %v, got kind %v from %v", tt.args, tt.expectedKind, tt.expectedStart, res.SuffixKind, res.SuffixStart)
+		}
+	}
+}
+
+func TestParseArgsOutputNames(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-l", "10", "--additional-suffix=.jsonl", "--name-template", "{prefix}{index:04}{ext}"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err := ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.AdditionalSuffix != ".jsonl" || res.NameTemplate != "{prefix}{index:04}{ext}" {
+		t.Errorf("expected %v and %v, got %v and %v", ".jsonl", "{prefix}{index:04}{ext}", res.AdditionalSuffix, res.NameTemplate)
+	}
+}
+
+func TestParseArgsOutputDir(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-l", "10", "-oparts", "--mkdir-mode", "0750"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err := ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.OutputDir != "parts" || res.MkdirMode != 0o750 {
+		t.Errorf("expected %v and %v, got %v and %v", "parts", os.FileMode(0o750), res.OutputDir, res.MkdirMode)
+	}
+}
+
+func TestParseArgsByteSizeWithUnit(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-b", "10M"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err := ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.ByteSize != 10*1024*1024 {
+		t.Errorf("expected %v, got %v", 10*1024*1024, res.ByteSize)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    int64
+		shouldError bool
+	}{
+		{input: "100", expected: 100},
+		{input: "10k", expected: 10 * 1024},
+		{input: "10K", expected: 10 * 1024},
+		{input: "10KiB", expected: 10 * 1024},
+		{input: "10KB", expected: 10000},
+		{input: "3m", expected: 3 * 1024 * 1024},
+		{input: "3MB", expected: 3000000},
+		{input: "2G", expected: 2 * 1024 * 1024 * 1024},
+		{input: "2GiB", expected: 2 * 1024 * 1024 * 1024},
+		{input: "2GB", expected: 2000000000},
+		{input: "7E", expected: 7 << 60},
+		{input: "8E", shouldError: true},
+		{input: "99999999999999999999", shouldError: true},
+		{input: "10X", shouldError: true},
+		{input: "M", shouldError: true},
+		{input: "-1", shouldError: true},
+		{input: "", shouldError: true},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseSize(tt.input)
+
+		if (err != nil) != tt.shouldError {
+			t.Fatalf("%q: expected error: %v, got: %v", tt.input, tt.shouldError, err)
+		}
+
+		if result != tt.expected {
+			t.Fatalf("%q: expected: %d, got: %d", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestParseArgsLineChunks(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-nl/4"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err := ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.FileCount != 4 || res.ChunkMode != splitter.ChunkLines {
+		t.Errorf("expected %v files in mode %v, got %v files in mode %v", 4, splitter.ChunkLines, res.FileCount, res.ChunkMode)
+	}
+}
+
+func TestChunkSpecSet(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    ChunkSpec
+		shouldError bool
+	}{
+		{input: "4", expected: ChunkSpec{Mode: splitter.ChunkBytes, Count: 4}},
+		{input: "l/4", expected: ChunkSpec{Mode: splitter.ChunkLines, Count: 4}},
+		{input: "2/4", expected: ChunkSpec{Mode: splitter.ChunkBytes, Index: 2, Count: 4}},
+		{input: "l/4/4", expected: ChunkSpec{Mode: splitter.ChunkLines, Index: 4, Count: 4}},
+		{input: "r/4", expected: ChunkSpec{Mode: splitter.ChunkRoundRobin, Count: 4}},
+		{input: "r/1/4", expected: ChunkSpec{Mode: splitter.ChunkRoundRobin, Index: 1, Count: 4}},
+		{input: "5/4", shouldError: true},
+		{input: "0/4", shouldError: true},
+		{input: "l/", shouldError: true},
+		{input: "x/4", shouldError: true},
+	}
+
+	for _, tt := range tests {
+		var result ChunkSpec
+		err := result.Set(tt.input)
+
+		if (err != nil) != tt.shouldError {
+			t.Fatalf("%q: expected error: %v, got: %v", tt.input, tt.shouldError, err)
+		}
+
+		if result != tt.expected {
+			t.Fatalf("%q: expected: %v, got: %v", tt.input, tt.expected, result)
+		}
+	}
+}
+
 func TestGetFileName(t *testing.T) {
 	tests := []struct {
 		nonFlagArgs []string
@@ -855,7 +361,7 @@ func TestGetFileName(t *testing.T) {
 
 	for _, tt := range tests {
 		reader := bufio.NewReader(bytes.NewBufferString(tt.input))
-		result, err := GetFileName(tt.nonFlagArgs, reader)
+		result, err := GetFileName(tt.nonFlagArgs, reader, true)
 
 		if (err != nil) != tt.shouldError {
 			t.Fatalf("expected error: %v, got: %v", tt.shouldError, err)
@@ -877,7 +383,7 @@ func TestGetFileNameWithValidFileName(t *testing.T) {
 	}
 
 	reader := bufio.NewReader(bytes.NewBufferString(""))
-	result, err := GetFileName(test.nonFlagArgs, reader)
+	result, err := GetFileName(test.nonFlagArgs, reader, true)
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -900,7 +406,7 @@ func TestGetFileNameWithInputString(t *testing.T) {
 	}
 
 	reader := bufio.NewReader(bytes.NewBufferString(test.input))
-	result, err := GetFileName(test.nonFlagArgs, reader)
+	result, err := GetFileName(test.nonFlagArgs, reader, true)
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -923,9 +429,232 @@ func TestGetFileNameWithError(t *testing.T) {
 	}
 
 	reader := bufio.NewReader(bytes.NewBufferString(test.input))
-	_, err := GetFileName(test.nonFlagArgs, reader)
+	_, err := GetFileName(test.nonFlagArgs, reader, true)
 
 	if (err == nil) == test.shouldError {
 		t.Fatalf("expected error: %v, got: %v", test.shouldError, err)
 	}
 }
+
+func TestGetFileNameDefaultsToStdin(t *testing.T) {
+	tests := []struct {
+		nonFlagArgs []string
+		expected    string
+	}{
+		{nonFlagArgs: []string{}, expected: StdinFileName},
+		{nonFlagArgs: []string{"-"}, expected: StdinFileName},
+		{nonFlagArgs: []string{"-", "part_"}, expected: StdinFileName},
+	}
+
+	for _, tt := range tests {
+		reader := bufio.NewReader(bytes.NewBufferString("inputfile.txt\n"))
+		result, err := GetFileName(tt.nonFlagArgs, reader, false)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result != tt.expected {
+			t.Fatalf("expected: %s, got: %s", tt.expected, result)
+		}
+	}
+}
+
+func TestNormalizeArgsKeepsLongOptions(t *testing.T) {
+	res := NormalizeArgs([]string{"--prompt", "-l10"})
+	expected := []string{"--prompt", "-l", "10"}
+
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
+
+func TestIllegalArgsCheckerStdin(t *testing.T) {
+	err := IllegalArgsChecker(Args{LineCount: 10, Args: []string{"-l", "10", "-", "part_"}})
+	if err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestParseArgsForce(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-l", "10", "--force"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err := ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !res.Force {
+		t.Errorf("expected %v, got %v", true, res.Force)
+	}
+}
+
+func TestParseArgsFsync(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-l", "10", "--fsync"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err := ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !res.Fsync {
+		t.Errorf("expected %v, got %v", true, res.Fsync)
+	}
+}
+
+func TestParseArgsKeepPartial(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-l", "10", "--keep-partial"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err := ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !res.KeepPartial {
+		t.Errorf("expected %v, got %v", true, res.KeepPartial)
+	}
+}
+
+func TestParseArgsElideEmptyFiles(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	for _, arg := range []string{"-e", "--elide-empty-files"} {
+		os.Args = []string{"./main", "-n", "10", arg}
+		fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+		res, err := ParseArgs(fs)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !res.ElideEmpty {
+			t.Errorf("%v: expected %v, got %v", arg, true, res.ElideEmpty)
+		}
+	}
+}
+
+func TestParseArgsFilter(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-b", "1G", "--filter=gzip > $FILE.gz"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err := ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Filter != "gzip > $FILE.gz" {
+		t.Errorf("expected %v, got %v", "gzip > $FILE.gz", res.Filter)
+	}
+}
+
+func TestParseArgsReport(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-l", "10", "--verbose", "--report=json"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err := ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Verbose || res.Report != "json" {
+		t.Errorf("expected %v and %v, got %v and %v", true, "json", res.Verbose, res.Report)
+	}
+
+	os.Args = []string{"./main", "-l", "10", "--report=xml"}
+	fs = flag.NewFlagSet("./main", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	_, err = ParseArgs(fs)
+	if err == nil || !strings.Contains(err.Error(), "xml: unknown report format") {
+		t.Errorf("expected an unknown report format error, got %v", err)
+	}
+
+	os.Args = []string{"./main", "-l", "10", "--report=json:report.json"}
+	fs = flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err = ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Report.Path() != "report.json" {
+		t.Errorf("expected %v, got %v", "report.json", res.Report.Path())
+	}
+
+	os.Args = []string{"./main", "-l", "10", "--report=json:"}
+	fs = flag.NewFlagSet("./main", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	_, err = ParseArgs(fs)
+	if err == nil || !strings.Contains(err.Error(), "json:: missing report path") {
+		t.Errorf("expected a missing report path error, got %v", err)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	result := splitter.Result{Parts: []splitter.PartResult{{Index: 0, Name: "xaa", Bytes: 2, Lines: 1}}}
+
+	if err := WriteReport(ReportFormat("json:"+path), result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	expected := `{"parts":[{"index":0,"name":"xaa","bytes":2,"lines":1}]}` + "\n"
+	if string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, string(content))
+	}
+}
+
+func TestIllegalArgsCheckerReportWithChunkIndex(t *testing.T) {
+	err := IllegalArgsChecker(Args{FileCount: 2, ChunkIndex: 1, Report: true, Args: []string{"-n", "1/2", "--report=json", "test.txt"}})
+	expected := fmt.Errorf("error: --report can't be used with -n K/N, which writes no split files")
+	if err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestParseArgsTimeout(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-l", "10", "--timeout", "1m30s"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	res, err := ParseArgs(fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Timeout != 90*time.Second {
+		t.Errorf("expected %v, got %v", 90*time.Second, res.Timeout)
+	}
+	if err := IllegalArgsChecker(Args{LineCount: 10, Args: res.Args}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	os.Args = []string{"./main", "-l", "10", "--timeout=-1s"}
+	fs = flag.NewFlagSet("./main", flag.ContinueOnError)
+	_, err = ParseArgs(fs)
+	if err == nil || err.Error() != "error: -1s: illegal timeout" {
+		t.Errorf("expected %v, got %v", "error: -1s: illegal timeout", err)
+	}
+}
+
+func TestParseArgsZeroSuffixLen(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"./main", "-l", "10", "-a", "0"}
+	fs := flag.NewFlagSet("./main", flag.ContinueOnError)
+	_, err := ParseArgs(fs)
+	expected := fmt.Errorf("Error: suffix length must be greater than 0")
+	if err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+}